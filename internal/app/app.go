@@ -5,9 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/fatalistix/crack-hash-worker/internal/config"
-	"github.com/fatalistix/crack-hash-worker/internal/http/client"
 	"github.com/fatalistix/crack-hash-worker/internal/http/handler"
+	"github.com/fatalistix/crack-hash-worker/internal/metrics"
 	"github.com/fatalistix/crack-hash-worker/internal/service"
+	"github.com/fatalistix/crack-hash-worker/internal/tracing"
 	"github.com/fatalistix/crack-hash-worker/internal/validation"
 	"github.com/fatalistix/slogattr"
 	"github.com/labstack/echo/v4"
@@ -16,13 +17,24 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
+type drainer interface {
+	Drain(ctx context.Context) error
+}
+
 type App struct {
-	e       *echo.Echo
-	log     *slog.Logger
-	closers []io.Closer
-	port    int
+	e                *echo.Echo
+	log              *slog.Logger
+	drainers         []drainer
+	closers          []io.Closer
+	port             int
+	registration     *service.Registration
+	registerDeadline time.Duration
+	registerCtx      context.Context
+	stopRegister     context.CancelFunc
+	registerErr      chan error
 }
 
 func New(log *slog.Logger, cfg config.Config) (*App, error) {
@@ -30,20 +42,57 @@ func New(log *slog.Logger, cfg config.Config) (*App, error) {
 
 	closers := make([]io.Closer, 0)
 
-	registerer := client.NewRegisterer(log)
+	tracerProvider, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		log.Error("failed to set up tracing", slogattr.Err(err))
+		return nil, fmt.Errorf("%s: error setting up tracing: %w", op, err)
+	}
+
+	m := metrics.New()
+
+	registration := service.NewRegistration(
+		log,
+		cfg.Manager.Address,
+		cfg.Deployment.Port,
+		cfg.Manager.RegisterMinBackoff,
+		cfg.Manager.RegisterMaxBackoff,
+	)
+
+	outbox, err := service.NewOutbox(
+		log,
+		cfg.Worker.OutboxDir,
+		cfg.Manager.Address,
+		registration,
+		cfg.Worker.OutboxMinBackoff,
+		cfg.Worker.OutboxMaxBackoff,
+		m,
+	)
+	if err != nil {
+		log.Error("failed to open outbox", slogattr.Err(err))
+		return nil, fmt.Errorf("%s: error opening outbox: %w", op, err)
+	}
 
-	id, err := registerer.Register(cfg.Manager.Address, cfg.Deployment.Port)
+	checkpoints, err := service.NewCheckpointStore(log, cfg.Worker.StateDir)
 	if err != nil {
-		log.Error("failed to register worker", slogattr.Err(err))
-		return nil, fmt.Errorf("%s: register error: %w", op, err)
+		log.Error("failed to open state directory", slogattr.Err(err))
+		return nil, fmt.Errorf("%s: error opening state directory: %w", op, err)
 	}
 
-	log.Info("worker registered", slog.String("worker id", id))
+	s := service.NewCrackService(log, cfg.Worker, registration, outbox, checkpoints, m)
+	m.WatchStatus(s.Status)
+	m.WatchHashRates(s.HashRates)
 
-	s := service.NewCrackService(log, cfg.Manager, cfg.Worker, id)
-	closers = append(closers, s)
+	heartbeat := service.NewHeartbeat(log, cfg.Manager.Address, cfg.Manager.HeartbeatInterval, registration, s)
+	closers = append(closers, heartbeat, s, outbox, tracerProvider)
+	drainers := []drainer{heartbeat, s, outbox, registration}
 
-	startHandler := handler.MakeStartTaskHandlerFunc(s)
+	startHandler := handler.MakeStartTaskHandlerFunc(s, cfg.Worker.RetryAfter)
+	cancelHandler := handler.MakeCancelTaskHandlerFunc(s)
+	statusHandler := handler.MakeStatusHandlerFunc(s)
+	taskProgressHandler := handler.MakeTaskProgressHandlerFunc(s)
+	tasksHandler := handler.MakeTasksHandlerFunc(s)
+	healthHandler := handler.MakeHealthHandlerFunc(s)
+	readinessHandler := handler.MakeReadinessHandlerFunc(s)
 
 	v, err := validation.NewRequestValidator()
 	if err != nil {
@@ -56,16 +105,31 @@ func New(log *slog.Logger, cfg config.Config) (*App, error) {
 	e.Validator = v
 
 	e.POST("/internal/api/worker/hash/crack/task", startHandler)
+	e.DELETE("/internal/api/worker/hash/crack/task/:task_id", cancelHandler)
+	e.GET("/internal/api/worker/hash/crack/task/:task_id", taskProgressHandler)
+	e.GET("/internal/api/worker/hash/crack/tasks", tasksHandler)
+	e.GET("/internal/api/worker/status", statusHandler)
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+	e.GET("/healthz", healthHandler)
+	e.GET("/readyz", readinessHandler)
 
 	e.Use(slogecho.New(log))
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestID())
 
+	registerCtx, stopRegister := context.WithCancel(context.Background())
+
 	return &App{
-		e:       e,
-		log:     log,
-		closers: closers,
-		port:    cfg.Deployment.Port,
+		e:                e,
+		log:              log,
+		drainers:         drainers,
+		closers:          closers,
+		port:             cfg.Deployment.Port,
+		registration:     registration,
+		registerDeadline: cfg.Manager.RegisterDeadline,
+		registerCtx:      registerCtx,
+		stopRegister:     stopRegister,
+		registerErr:      make(chan error, 1),
 	}, nil
 }
 
@@ -80,6 +144,8 @@ func (a *App) Start() error {
 
 	address := fmt.Sprintf(":%d", a.port)
 
+	go a.register()
+
 	if err := a.e.Start(address); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Error("error starting http server", slog.Int("port", a.port), slogattr.Err(err))
 		return fmt.Errorf("%s: error starting http server: %w", op, err)
@@ -87,7 +153,40 @@ func (a *App) Start() error {
 
 	log.Info("server stopped", slog.Int("port", a.port))
 
-	return nil
+	select {
+	case err := <-a.registerErr:
+		return fmt.Errorf("%s: register error: %w", op, err)
+	default:
+		return nil
+	}
+}
+
+func (a *App) register() {
+	const op = "app.register"
+
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	ctx := a.registerCtx
+	if a.registerDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.registerDeadline)
+		defer cancel()
+	}
+
+	err := a.registration.Register(ctx)
+	if err == nil || a.registerCtx.Err() != nil {
+		return
+	}
+
+	log.Error("failed to register worker before deadline", slog.Duration("deadline", a.registerDeadline), slogattr.Err(err))
+
+	a.registerErr <- err
+
+	if err := a.e.Shutdown(context.Background()); err != nil {
+		log.Error("error stopping http server", slogattr.Err(err))
+	}
 }
 
 func (a *App) Stop(ctx context.Context) error {
@@ -98,6 +197,13 @@ func (a *App) Stop(ctx context.Context) error {
 	)
 
 	defer a.close()
+	defer func() {
+		if err := a.drain(ctx); err != nil {
+			log.Warn("services were not drained cleanly", slogattr.Err(err))
+		}
+	}()
+
+	a.stopRegister()
 
 	log.Info("stopping server", slog.Int("port", a.port))
 
@@ -111,6 +217,32 @@ func (a *App) Stop(ctx context.Context) error {
 	return nil
 }
 
+func (a *App) drain(ctx context.Context) error {
+	const op = "app.drain"
+
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("draining services...")
+
+	errs := make([]error, 0)
+	for _, drainer := range a.drainers {
+		if err := drainer.Drain(ctx); err != nil {
+			log.Error("error draining service", slogattr.Err(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	log.Info("all services drained")
+
+	return nil
+}
+
 func (a *App) close() {
 	const op = "app.close"
 
@@ -24,6 +24,7 @@ type Config struct {
 	Deployment DeploymentConfig
 	Manager    ManagerConfig
 	Worker     WorkerConfig
+	Tracing    TracingConfig
 }
 
 type DeploymentConfig struct {
@@ -32,10 +33,33 @@ type DeploymentConfig struct {
 }
 
 type ManagerConfig struct {
-	Address string `env:"MANAGER_ADDRESS"`
+	Address            string        `env:"MANAGER_ADDRESS"`
+	RegisterDeadline   time.Duration `env:"MANAGER_REGISTER_DEADLINE"`
+	RegisterMinBackoff time.Duration `env:"MANAGER_REGISTER_MIN_BACKOFF"`
+	RegisterMaxBackoff time.Duration `env:"MANAGER_REGISTER_MAX_BACKOFF"`
+	HeartbeatInterval  time.Duration `env:"MANAGER_HEARTBEAT_INTERVAL"`
 }
 
 type WorkerConfig struct {
-	GoroutineCount uint64        `env:"WORKER_GOROUTINE_COUNT"`
-	SubTaskTimeout time.Duration `env:"WORKER_SUB_TASK_TIMEOUT"`
+	GoroutineCount     uint64        `env:"WORKER_GOROUTINE_COUNT"`
+	ChunkTimeout       time.Duration `env:"WORKER_CHUNK_TIMEOUT" env-default:"5s"` // per dispatched chunk, tasks have no wall-clock limit
+	WordlistDir        string        `env:"WORKER_WORDLIST_DIR"`
+	WordlistIndexDir   string        `env:"WORKER_WORDLIST_INDEX_DIR"`
+	QueueSize          uint64        `env:"WORKER_QUEUE_SIZE" env-default:"16"`
+	RetryAfter         time.Duration `env:"WORKER_RETRY_AFTER"`
+	AggregationTTL     time.Duration `env:"WORKER_AGGREGATION_TTL"`
+	InitialChunkSize   uint64        `env:"WORKER_INITIAL_CHUNK_SIZE"`
+	ChunkDuration      time.Duration `env:"WORKER_CHUNK_DURATION"`
+	OutboxDir          string        `env:"WORKER_OUTBOX_DIR"`
+	OutboxMinBackoff   time.Duration `env:"WORKER_OUTBOX_MIN_BACKOFF"`
+	OutboxMaxBackoff   time.Duration `env:"WORKER_OUTBOX_MAX_BACKOFF"`
+	StateDir           string        `env:"WORKER_STATE_DIR"`
+	CheckpointInterval time.Duration `env:"WORKER_CHECKPOINT_INTERVAL"`
+}
+
+type TracingConfig struct {
+	Exporter     string `env:"TRACING_EXPORTER"`
+	File         string `env:"TRACING_FILE"`
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool   `env:"TRACING_OTLP_INSECURE"`
 }
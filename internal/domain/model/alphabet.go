@@ -0,0 +1,6 @@
+package model
+
+const (
+	AlphabetEncodingUTF8 = "utf8"
+	AlphabetEncodingHex  = "hex"
+)
@@ -0,0 +1,21 @@
+package model
+
+import "errors"
+
+var (
+	ErrRangeOutOfKeyspace = errors.New("task range is out of keyspace")
+	ErrInvalidAlphabet    = errors.New("invalid alphabet")
+	ErrInvalidMask        = errors.New("invalid mask")
+	ErrUnknownMode        = errors.New("unknown attack mode")
+	ErrUnknownWordlist    = errors.New("unknown wordlist")
+	ErrInvalidRule        = errors.New("invalid rule")
+	ErrMultipleTargets    = errors.New("stop on first match requires a single target hash")
+	ErrQueueFull          = errors.New("task queue is full")
+	ErrNotReady           = errors.New("worker is not registered yet")
+	ErrShuttingDown       = errors.New("worker is shutting down")
+	ErrTaskConflict       = errors.New("task already exists with a different payload")
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrTaskCancelled      = errors.New("task cancelled")
+	ErrMatchFound         = errors.New("match found")
+	ErrTaskExpired        = errors.New("task aggregation expired")
+)
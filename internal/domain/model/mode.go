@@ -0,0 +1,7 @@
+package model
+
+const (
+	ModeBruteForce = "brute"
+	ModeMask       = "mask"
+	ModeDictionary = "dictionary"
+)
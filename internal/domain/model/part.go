@@ -1,20 +1,29 @@
 package model
 
 type Part struct {
-	RequestId string
-	TaskId    string
-	Alphabet  string
-	Hash      string
-	MaxLength uint64
-	Start     uint64
-	End       uint64
+	RequestId        string
+	TaskId           string
+	Mode             string
+	Mask             string
+	CustomCharsets   []string
+	Wordlist         string
+	Rules            []string
+	Alphabet         string
+	AlphabetEncoding string
+	Algorithm        string
+	Hashes           []string
+	MaxLength        uint64
+	Start            uint64
+	End              uint64
+	StopOnFirstMatch bool
 }
 
 type CompletedPart struct {
 	RequestId string
 	TaskId    string
-	Data      []string
+	Matches   map[string][]string
 	Start     uint64
 	End       uint64
+	Reached   uint64
 	Error     error
 }
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+const (
+	TaskStateQueued  = "queued"
+	TaskStateRunning = "running"
+)
+
+const (
+	PartStatePending   = "pending"
+	PartStateRunning   = "running"
+	PartStateCompleted = "completed"
+	PartStateFound     = "found"
+	PartStateTimedOut  = "timed_out"
+	PartStateCancelled = "cancelled"
+	PartStateFailed    = "failed"
+)
+
+type TaskProgress struct {
+	RequestId       string
+	TaskId          string
+	State           string
+	Start           uint64
+	End             uint64
+	Dispatched      uint64
+	Tested          uint64
+	FinishedParts   uint64
+	HashesPerSecond float64
+	Eta             time.Duration
+	Parts           []PartProgress
+}
+
+type PartProgress struct {
+	Index           int
+	State           string
+	Start           uint64
+	End             uint64
+	Current         uint64
+	Tested          uint64
+	HashesPerSecond float64
+	Eta             time.Duration
+}
@@ -0,0 +1,6 @@
+package model
+
+type Range struct {
+	Start uint64
+	End   uint64
+}
@@ -0,0 +1,12 @@
+package model
+
+type TaskResult struct {
+	RequestId string
+	TaskId    string
+	Status    string
+	Start     uint64
+	End       uint64
+	Matches   map[string][]string
+	Covered   []Range
+	Uncovered []Range
+}
@@ -0,0 +1,29 @@
+package model
+
+type WorkerStatus struct {
+	QueueDepth    int
+	QueueCapacity int
+	Workers       uint64
+	BusyWorkers   uint64
+	Registered    bool
+}
+
+type WorkerHealth struct {
+	Alive           bool
+	StuckGoroutines uint64
+}
+
+type WorkerReadiness struct {
+	Ready          bool
+	Registered     bool
+	PoolRunning    bool
+	QueueSaturated bool
+}
+
+const (
+	TaskStatusCompleted = "completed"
+	TaskStatusCancelled = "cancelled"
+	TaskStatusFound     = "found"
+	TaskStatusPartial   = "partial"
+	TaskStatusExpired   = "expired"
+)
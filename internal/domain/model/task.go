@@ -1,11 +1,19 @@
 package model
 
 type Task struct {
-	RequestId string
-	TaskId    string
-	Alphabet  string
-	Hash      string
-	MaxLength uint64
-	Start     uint64
-	End       uint64
+	RequestId        string
+	TaskId           string
+	Mode             string
+	Mask             string
+	CustomCharsets   []string
+	Wordlist         string
+	Rules            []string
+	Alphabet         string
+	AlphabetEncoding string
+	Algorithm        string
+	Hashes           []string
+	MaxLength        uint64
+	Start            uint64
+	End              uint64
+	StopOnFirstMatch bool
 }
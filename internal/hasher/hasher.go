@@ -0,0 +1,140 @@
+package hasher
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/sha3"
+	"sort"
+)
+
+const (
+	MD5        = "md5"
+	SHA1       = "sha1"
+	SHA224     = "sha224"
+	SHA256     = "sha256"
+	SHA384     = "sha384"
+	SHA512     = "sha512"
+	SHA3224    = "sha3-224"
+	SHA3256    = "sha3-256"
+	SHA3384    = "sha3-384"
+	SHA3512    = "sha3-512"
+	BLAKE2b256 = "blake2b-256"
+	BLAKE2b384 = "blake2b-384"
+	BLAKE2b512 = "blake2b-512"
+	BLAKE2s256 = "blake2s-256"
+
+	Default = MD5
+)
+
+type Hasher interface {
+	Name() string
+	Size() int
+	Sum(dst, data []byte) []byte
+}
+
+type sumHasher struct {
+	name string
+	size int
+	sum  func(dst, data []byte) []byte
+}
+
+func (h sumHasher) Name() string {
+	return h.name
+}
+
+func (h sumHasher) Size() int {
+	return h.size
+}
+
+func (h sumHasher) Sum(dst, data []byte) []byte {
+	return h.sum(dst, data)
+}
+
+var registry = map[string]Hasher{}
+
+func init() {
+	register(MD5, md5.Size, func(dst, data []byte) []byte {
+		sum := md5.Sum(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA1, sha1.Size, func(dst, data []byte) []byte {
+		sum := sha1.Sum(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA224, sha256.Size224, func(dst, data []byte) []byte {
+		sum := sha256.Sum224(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA256, sha256.Size, func(dst, data []byte) []byte {
+		sum := sha256.Sum256(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA384, sha512.Size384, func(dst, data []byte) []byte {
+		sum := sha512.Sum384(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA512, sha512.Size, func(dst, data []byte) []byte {
+		sum := sha512.Sum512(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA3224, 28, func(dst, data []byte) []byte {
+		sum := sha3.Sum224(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA3256, 32, func(dst, data []byte) []byte {
+		sum := sha3.Sum256(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA3384, 48, func(dst, data []byte) []byte {
+		sum := sha3.Sum384(data)
+		return append(dst, sum[:]...)
+	})
+	register(SHA3512, 64, func(dst, data []byte) []byte {
+		sum := sha3.Sum512(data)
+		return append(dst, sum[:]...)
+	})
+	register(BLAKE2b256, blake2b.Size256, func(dst, data []byte) []byte {
+		sum := blake2b.Sum256(data)
+		return append(dst, sum[:]...)
+	})
+	register(BLAKE2b384, blake2b.Size384, func(dst, data []byte) []byte {
+		sum := blake2b.Sum384(data)
+		return append(dst, sum[:]...)
+	})
+	register(BLAKE2b512, blake2b.Size, func(dst, data []byte) []byte {
+		sum := blake2b.Sum512(data)
+		return append(dst, sum[:]...)
+	})
+	register(BLAKE2s256, blake2s.Size, func(dst, data []byte) []byte {
+		sum := blake2s.Sum256(data)
+		return append(dst, sum[:]...)
+	})
+}
+
+func register(name string, size int, sum func(dst, data []byte) []byte) {
+	registry[name] = sumHasher{
+		name: name,
+		size: size,
+		sum:  sum,
+	}
+}
+
+func Lookup(name string) (Hasher, bool) {
+	h, ok := registry[name]
+	return h, ok
+}
+
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,35 @@
+package hasher
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHasher_Sum(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		expected  string
+	}{
+		{MD5, "900150983cd24fb0d6963f7d28e17f72"},
+		{SHA1, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{SHA256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{SHA3256, "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{BLAKE2s256, "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"},
+	}
+
+	for _, test := range tests {
+		h, ok := Lookup(test.algorithm)
+		assert.True(t, ok)
+
+		sum := h.Sum(nil, []byte("abc"))
+
+		assert.Len(t, sum, h.Size())
+		assert.Equal(t, test.expected, hex.EncodeToString(sum))
+	}
+}
+
+func TestLookup_Unknown(t *testing.T) {
+	_, ok := Lookup("crc32")
+	assert.False(t, ok)
+}
@@ -1,7 +1,26 @@
 package client
 
+import (
+	"github.com/fatalistix/slogattr"
+	"io"
+	"log/slog"
+)
+
 const basePath = "/internal/api/worker/hash/crack"
 
 func makeUrl(address, path string) string {
 	return "http://" + address + path
 }
+
+func closeOrLog(log *slog.Logger, closer io.Closer) {
+	const op = "http.client.close"
+
+	if err := closer.Close(); err != nil {
+		log.Error(
+			"unable to close",
+			slog.String("op", op),
+			slog.Any("closer", closer),
+			slogattr.Err(err),
+		)
+	}
+}
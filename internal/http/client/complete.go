@@ -2,35 +2,54 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fatalistix/slogattr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
-const completePath = basePath + "/request"
+const (
+	completePath    = basePath + "/request"
+	completeTimeout = 10 * time.Second
+)
 
 type CompleteRequest struct {
-	RequestId string   `json:"request_id"`
-	TaskId    string   `json:"task_id"`
-	WorkerId  string   `json:"worker_id"`
-	Start     uint64   `json:"start"`
-	End       uint64   `json:"end"`
-	Data      []string `json:"data"`
+	RequestId string              `json:"request_id"`
+	TaskId    string              `json:"task_id"`
+	WorkerId  string              `json:"worker_id"`
+	Status    string              `json:"status"`
+	Start     uint64              `json:"start"`
+	End       uint64              `json:"end"`
+	Matches   map[string][]string `json:"matches"`
+	Covered   []Range             `json:"covered"`
+	Uncovered []Range             `json:"uncovered"`
+}
+
+type Range struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
 }
 
 type Completer struct {
-	log *slog.Logger
+	log    *slog.Logger
+	client *http.Client
 }
 
 func NewCompleter(log *slog.Logger) *Completer {
 	return &Completer{
 		log: log,
+		client: &http.Client{
+			Timeout: completeTimeout,
+		},
 	}
 }
 
-func (c *Completer) Complete(managerAddress string, request CompleteRequest) error {
+func (c *Completer) Complete(ctx context.Context, managerAddress string, request CompleteRequest) error {
 	const op = "http.client.Completer.Complete"
 
 	log := c.log.With(
@@ -45,19 +64,22 @@ func (c *Completer) Complete(managerAddress string, request CompleteRequest) err
 		return fmt.Errorf("%s: error marshaling request %w", op, err)
 	}
 
-	httpRequest, err := http.NewRequest(http.MethodPatch, makeUrl(managerAddress, completePath), bytes.NewBuffer(requestBytes))
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPatch, makeUrl(managerAddress, completePath), bytes.NewBuffer(requestBytes))
 	if err != nil {
 		log.Error("error creating http request", slogattr.Err(err))
 		return fmt.Errorf("%s: error creating http request %w", op, err)
 	}
 
 	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Idempotency-Key", request.TaskId)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpRequest.Header))
 
-	httpResponse, err := http.DefaultClient.Do(httpRequest)
+	httpResponse, err := c.client.Do(httpRequest)
 	if err != nil {
 		log.Error("error executing http request", slogattr.Err(err))
 		return fmt.Errorf("%s: error executing http request %w", op, err)
 	}
+	defer closeOrLog(c.log, httpResponse.Body)
 
 	if httpResponse.StatusCode != http.StatusAccepted {
 		log.Error("error completing task: unexpected http status code", slog.Int("status code", httpResponse.StatusCode))
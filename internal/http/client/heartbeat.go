@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatalistix/slogattr"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	heartbeatPath    = basePath + "/heartbeat"
+	heartbeatTimeout = 5 * time.Second
+)
+
+var ErrUnknownWorker = errors.New("worker is unknown to manager")
+
+type HeartbeatRequest struct {
+	WorkerId      string   `json:"worker_id"`
+	Workers       uint64   `json:"workers"`
+	BusyWorkers   uint64   `json:"busy_workers"`
+	QueueDepth    int      `json:"queue_depth"`
+	QueueCapacity int      `json:"queue_capacity"`
+	ActiveTaskIds []string `json:"active_task_ids"`
+}
+
+type Heartbeater struct {
+	log    *slog.Logger
+	client *http.Client
+}
+
+func NewHeartbeater(log *slog.Logger) *Heartbeater {
+	return &Heartbeater{
+		log: log,
+		client: &http.Client{
+			Timeout: heartbeatTimeout,
+		},
+	}
+}
+
+func (h *Heartbeater) Heartbeat(ctx context.Context, managerAddress string, request HeartbeatRequest) error {
+	const op = "http.client.Heartbeater.Heartbeat"
+
+	log := h.log.With(
+		slog.String("op", op),
+	)
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		log.Error("error marshaling heartbeat request", slogattr.Err(err))
+		return fmt.Errorf("%s: error marshaling request: %w", op, err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, makeUrl(managerAddress, heartbeatPath), bytes.NewBuffer(requestBytes))
+	if err != nil {
+		log.Error("error creating http request", slogattr.Err(err))
+		return fmt.Errorf("%s: error creating http request: %w", op, err)
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := h.client.Do(httpRequest)
+	if err != nil {
+		log.Error("error sending heartbeat", slogattr.Err(err))
+		return fmt.Errorf("%s: error sending request: %w", op, err)
+	}
+	defer closeOrLog(h.log, httpResponse.Body)
+
+	_, _ = io.Copy(io.Discard, httpResponse.Body)
+
+	switch httpResponse.StatusCode {
+	case http.StatusAccepted, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		log.Warn("manager does not know worker", slog.String("worker id", request.WorkerId))
+		return fmt.Errorf("%s: worker %q: %w", op, request.WorkerId, ErrUnknownWorker)
+	default:
+		log.Error("error sending heartbeat: unexpected status code", slog.Int("status code", httpResponse.StatusCode))
+		return fmt.Errorf("%s: unexpected status code %s", op, httpResponse.Status)
+	}
+}
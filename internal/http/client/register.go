@@ -2,15 +2,20 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fatalistix/slogattr"
-	"io"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
-const registerPath = basePath + "/register"
+const (
+	registerPath    = basePath + "/register"
+	deregisterPath  = basePath + "/deregister"
+	registerTimeout = 10 * time.Second
+)
 
 type RegisterRequest struct {
 	WorkerPort int `json:"worker_port"`
@@ -20,17 +25,25 @@ type RegisterResponse struct {
 	WorkerId string `json:"worker_id"`
 }
 
+type DeregisterRequest struct {
+	WorkerId string `json:"worker_id"`
+}
+
 type Registerer struct {
-	log *slog.Logger
+	log    *slog.Logger
+	client *http.Client
 }
 
 func NewRegisterer(log *slog.Logger) *Registerer {
 	return &Registerer{
 		log: log,
+		client: &http.Client{
+			Timeout: registerTimeout,
+		},
 	}
 }
 
-func (r *Registerer) Register(managerAddress string, workerPort int) (string, error) {
+func (r *Registerer) Register(ctx context.Context, managerAddress string, workerPort int) (string, error) {
 	const op = "http.client.Registerer.Register"
 
 	log := r.log.With(
@@ -47,13 +60,21 @@ func (r *Registerer) Register(managerAddress string, workerPort int) (string, er
 		return "", fmt.Errorf("%s: error marshaling request: %w", op, err)
 	}
 
-	httpResponse, err := http.Post(makeUrl(managerAddress, registerPath), "application/json", bytes.NewBuffer(requestBytes))
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, makeUrl(managerAddress, registerPath), bytes.NewBuffer(requestBytes))
+	if err != nil {
+		log.Error("error creating http request", slogattr.Err(err))
+		return "", fmt.Errorf("%s: error creating http request: %w", op, err)
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := r.client.Do(httpRequest)
 	if err != nil {
 		log.Error("error sending request", slog.Any("request", request), slogattr.Err(err))
 		return "", fmt.Errorf("%s: error sending request: %w", op, err)
 	}
 
-	defer r.closeOrLog(httpResponse.Body)
+	defer closeOrLog(r.log, httpResponse.Body)
 
 	if httpResponse.StatusCode != http.StatusAccepted {
 		log.Error("error registering worker: unexpected status code", slog.Any("request", request), slog.Int("status code", httpResponse.StatusCode))
@@ -72,15 +93,43 @@ func (r *Registerer) Register(managerAddress string, workerPort int) (string, er
 	return response.WorkerId, nil
 }
 
-func (r *Registerer) closeOrLog(closer io.Closer) {
-	const op = "http.client.close"
+func (r *Registerer) Deregister(ctx context.Context, managerAddress string, workerId string) error {
+	const op = "http.client.Registerer.Deregister"
 
-	if err := closer.Close(); err != nil {
-		r.log.Error(
-			"unable to close",
-			slog.String("op", op),
-			slog.Any("closer", closer),
-			slogattr.Err(err),
-		)
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	request := DeregisterRequest{
+		WorkerId: workerId,
 	}
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		log.Error("error marshaling deregister request", slog.Any("request", request), slogattr.Err(err))
+		return fmt.Errorf("%s: error marshaling request: %w", op, err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, makeUrl(managerAddress, deregisterPath), bytes.NewBuffer(requestBytes))
+	if err != nil {
+		log.Error("error creating http request", slogattr.Err(err))
+		return fmt.Errorf("%s: error creating http request: %w", op, err)
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := r.client.Do(httpRequest)
+	if err != nil {
+		log.Error("error sending request", slog.Any("request", request), slogattr.Err(err))
+		return fmt.Errorf("%s: error sending request: %w", op, err)
+	}
+
+	defer closeOrLog(r.log, httpResponse.Body)
+
+	if httpResponse.StatusCode != http.StatusAccepted {
+		log.Error("error deregistering worker: unexpected status code", slog.Any("request", request), slog.Int("status code", httpResponse.StatusCode))
+		return fmt.Errorf("%s: error deregistering worker: unexpected status code %s", op, httpResponse.Status)
+	}
+
+	return nil
 }
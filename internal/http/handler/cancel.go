@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type TaskCanceller interface {
+	CancelTask(taskId string) error
+}
+
+func MakeCancelTaskHandlerFunc(taskCanceller TaskCanceller) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		taskId := c.Param("task_id")
+
+		if err := taskCanceller.CancelTask(taskId); err != nil {
+			if errors.Is(err, model.ErrTaskNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "task not found").SetInternal(err)
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, "unable to cancel task").SetInternal(err)
+		}
+
+		return c.JSON(http.StatusAccepted, nil)
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type HealthChecker interface {
+	Health() model.WorkerHealth
+}
+
+type ReadinessChecker interface {
+	Readiness() model.WorkerReadiness
+}
+
+type HealthResponse struct {
+	Alive           bool   `json:"alive"`
+	StuckGoroutines uint64 `json:"stuck_goroutines"`
+}
+
+type ReadinessResponse struct {
+	Ready          bool `json:"ready"`
+	Registered     bool `json:"registered"`
+	PoolRunning    bool `json:"pool_running"`
+	QueueSaturated bool `json:"queue_saturated"`
+}
+
+func MakeHealthHandlerFunc(healthChecker HealthChecker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		health := healthChecker.Health()
+
+		code := http.StatusOK
+		if !health.Alive {
+			code = http.StatusServiceUnavailable
+		}
+
+		return c.JSON(code, HealthResponse{
+			Alive:           health.Alive,
+			StuckGoroutines: health.StuckGoroutines,
+		})
+	}
+}
+
+func MakeReadinessHandlerFunc(readinessChecker ReadinessChecker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		readiness := readinessChecker.Readiness()
+
+		code := http.StatusOK
+		if !readiness.Ready {
+			code = http.StatusServiceUnavailable
+		}
+
+		return c.JSON(code, ReadinessResponse{
+			Ready:          readiness.Ready,
+			Registered:     readiness.Registered,
+			PoolRunning:    readiness.PoolRunning,
+			QueueSaturated: readiness.QueueSaturated,
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type TaskProgressProvider interface {
+	TaskProgress(taskId string) (model.TaskProgress, error)
+}
+
+type TasksProvider interface {
+	Tasks() []model.TaskProgress
+}
+
+type TaskProgressResponse struct {
+	RequestId       string                 `json:"request_id"`
+	TaskId          string                 `json:"task_id"`
+	State           string                 `json:"state"`
+	Start           uint64                 `json:"start"`
+	End             uint64                 `json:"end"`
+	Dispatched      uint64                 `json:"dispatched"`
+	Tested          uint64                 `json:"tested"`
+	FinishedParts   uint64                 `json:"finished_parts"`
+	HashesPerSecond float64                `json:"hashes_per_second"`
+	EtaSeconds      float64                `json:"eta_seconds"`
+	Parts           []PartProgressResponse `json:"parts,omitempty"`
+}
+
+type PartProgressResponse struct {
+	Index           int     `json:"index"`
+	State           string  `json:"state"`
+	Start           uint64  `json:"start"`
+	End             uint64  `json:"end"`
+	Current         uint64  `json:"current"`
+	Tested          uint64  `json:"tested"`
+	HashesPerSecond float64 `json:"hashes_per_second"`
+	EtaSeconds      float64 `json:"eta_seconds"`
+}
+
+type TasksResponse struct {
+	Tasks []TaskProgressResponse `json:"tasks"`
+}
+
+func MakeTaskProgressHandlerFunc(taskProgressProvider TaskProgressProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		taskId := c.Param("task_id")
+
+		progress, err := taskProgressProvider.TaskProgress(taskId)
+		if err != nil {
+			if errors.Is(err, model.ErrTaskNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "task not found").SetInternal(err)
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, "unable to get task progress").SetInternal(err)
+		}
+
+		return c.JSON(http.StatusOK, MapTaskProgressToResponse(progress))
+	}
+}
+
+func MakeTasksHandlerFunc(tasksProvider TasksProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tasks := tasksProvider.Tasks()
+
+		response := TasksResponse{
+			Tasks: make([]TaskProgressResponse, len(tasks)),
+		}
+		for i, task := range tasks {
+			response.Tasks[i] = MapTaskProgressToResponse(task)
+		}
+
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+func MapTaskProgressToResponse(progress model.TaskProgress) TaskProgressResponse {
+	response := TaskProgressResponse{
+		RequestId:       progress.RequestId,
+		TaskId:          progress.TaskId,
+		State:           progress.State,
+		Start:           progress.Start,
+		End:             progress.End,
+		Dispatched:      progress.Dispatched,
+		Tested:          progress.Tested,
+		FinishedParts:   progress.FinishedParts,
+		HashesPerSecond: progress.HashesPerSecond,
+		EtaSeconds:      progress.Eta.Seconds(),
+	}
+
+	if progress.Parts != nil {
+		response.Parts = make([]PartProgressResponse, len(progress.Parts))
+		for i, part := range progress.Parts {
+			response.Parts[i] = PartProgressResponse{
+				Index:           part.Index,
+				State:           part.State,
+				Start:           part.Start,
+				End:             part.End,
+				Current:         part.Current,
+				Tested:          part.Tested,
+				HashesPerSecond: part.HashesPerSecond,
+				EtaSeconds:      part.Eta.Seconds(),
+			}
+		}
+	}
+
+	return response
+}
@@ -1,53 +1,146 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/crack-hash-worker/internal/hasher"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"math"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TaskStarter interface {
-	StartTask(model.Task)
+	StartTask(ctx context.Context, task model.Task) (model.TaskProgress, bool, error)
 }
 
 type TaskRequest struct {
-	RequestId string `json:"request_id" validate:"required"`
-	TaskId    string `json:"task_id" validate:"required"`
-	Alphabet  string `json:"alphabet" validate:"required,uniquechars"`
-	Hash      string `json:"hash" validate:"required,md5hash"`
-	MaxLength uint64 `json:"max_length" validate:"required,min=1"`
-	Start     uint64 `json:"start" validate:"min=0"`
-	End       uint64 `json:"end" validate:"required,gtfield=Start"`
+	RequestId        string   `json:"request_id" validate:"required"`
+	TaskId           string   `json:"task_id" validate:"required"`
+	Mode             string   `json:"mode" validate:"required,oneof=brute mask dictionary"`
+	Mask             string   `json:"mask" validate:"required_if=Mode mask"`
+	CustomCharsets   []string `json:"custom_charsets" validate:"max=4,dive,required"`
+	Wordlist         string   `json:"wordlist" validate:"required_if=Mode dictionary"`
+	Rules            []string `json:"rules" validate:"excluded_unless=Mode dictionary,dive,required"`
+	Alphabet         string   `json:"alphabet" validate:"required_if=Mode brute,omitempty,alphabet=AlphabetEncoding"`
+	AlphabetEncoding string   `json:"alphabet_encoding" validate:"required,oneof=utf8 hex"`
+	Algorithm        string   `json:"algorithm" validate:"required,hashalgorithm"`
+	Hashes           []string `json:"hashes" validate:"required,min=1,dive,digest=Algorithm"`
+	MaxLength        uint64   `json:"max_length" validate:"required_if=Mode brute,omitempty,min=1,max=256"`
+	Start            uint64   `json:"start" validate:"min=0"`
+	End              uint64   `json:"end" validate:"required,gtfield=Start"`
+	StopOnFirstMatch bool     `json:"stop_on_first_match"`
 }
 
-func MakeStartTaskHandlerFunc(taskStarter TaskStarter) echo.HandlerFunc {
+func MakeStartTaskHandlerFunc(taskStarter TaskStarter, retryAfter time.Duration) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var request TaskRequest
 
 		if err := c.Bind(&request); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Type.Kind() == reflect.Uint64 {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("invalid request body: field %q must be an unsigned 64-bit integer, got %s", typeErr.Field, typeErr.Value)).SetInternal(typeErr)
+			}
+
 			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "invalid request body").SetInternal(err)
 		}
 
+		if request.Mode == "" {
+			request.Mode = model.ModeBruteForce
+		}
+
+		if request.AlphabetEncoding == "" {
+			request.AlphabetEncoding = model.AlphabetEncodingUTF8
+		}
+
+		if request.Algorithm == "" {
+			request.Algorithm = hasher.Default
+		}
+
 		if err := c.Validate(request); err != nil {
 			return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("invalid request body: %s", err.Error())).SetInternal(err)
 		}
 
 		task := MapRequestToModel(request)
-		taskStarter.StartTask(task)
+		ctx := otel.GetTextMapPropagator().Extract(c.Request().Context(), propagation.HeaderCarrier(c.Request().Header))
+
+		progress, created, err := taskStarter.StartTask(ctx, task)
+		if err != nil {
+			if isInvalidTaskError(err) {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("invalid request body: %s", err.Error())).SetInternal(err)
+			}
+
+			if errors.Is(err, model.ErrTaskConflict) {
+				return echo.NewHTTPError(http.StatusConflict, "task already exists with a different payload").SetInternal(err)
+			}
+
+			if errors.Is(err, model.ErrQueueFull) || errors.Is(err, model.ErrShuttingDown) || errors.Is(err, model.ErrNotReady) {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "unable to accept task").SetInternal(err)
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, "unable to start task").SetInternal(err)
+		}
 
-		return c.JSON(http.StatusOK, nil)
+		if !created {
+			return c.JSON(http.StatusOK, MapTaskProgressToResponse(progress))
+		}
+
+		return c.JSON(http.StatusAccepted, nil)
 	}
 }
 
+func isInvalidTaskError(err error) bool {
+	return errors.Is(err, model.ErrRangeOutOfKeyspace) ||
+		errors.Is(err, model.ErrInvalidAlphabet) ||
+		errors.Is(err, model.ErrInvalidMask) ||
+		errors.Is(err, model.ErrUnknownMode) ||
+		errors.Is(err, model.ErrUnknownWordlist) ||
+		errors.Is(err, model.ErrInvalidRule) ||
+		errors.Is(err, model.ErrMultipleTargets)
+}
+
 func MapRequestToModel(request TaskRequest) model.Task {
 	return model.Task{
-		RequestId: request.RequestId,
-		TaskId:    request.TaskId,
-		Alphabet:  request.Alphabet,
-		Hash:      request.Hash,
-		MaxLength: request.MaxLength,
-		Start:     request.Start,
-		End:       request.End,
+		RequestId:        request.RequestId,
+		TaskId:           request.TaskId,
+		Mode:             request.Mode,
+		Mask:             request.Mask,
+		CustomCharsets:   request.CustomCharsets,
+		Wordlist:         request.Wordlist,
+		Rules:            request.Rules,
+		Alphabet:         request.Alphabet,
+		AlphabetEncoding: request.AlphabetEncoding,
+		Algorithm:        request.Algorithm,
+		Hashes:           normalizeHashes(request.Hashes),
+		MaxLength:        request.MaxLength,
+		Start:            request.Start,
+		End:              request.End,
+		StopOnFirstMatch: request.StopOnFirstMatch,
 	}
 }
+
+func normalizeHashes(hashes []string) []string {
+	normalized := make([]string, 0, len(hashes))
+	visited := make(map[string]bool, len(hashes))
+
+	for _, hash := range hashes {
+		hash = strings.ToLower(hash)
+		if visited[hash] {
+			continue
+		}
+
+		visited[hash] = true
+		normalized = append(normalized, hash)
+	}
+
+	return normalized
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type StatusProvider interface {
+	Status() model.WorkerStatus
+}
+
+type StatusResponse struct {
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Workers       uint64 `json:"workers"`
+	BusyWorkers   uint64 `json:"busy_workers"`
+	Registered    bool   `json:"registered"`
+}
+
+func MakeStatusHandlerFunc(statusProvider StatusProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status := statusProvider.Status()
+
+		return c.JSON(http.StatusOK, MapStatusToResponse(status))
+	}
+}
+
+func MapStatusToResponse(status model.WorkerStatus) StatusResponse {
+	return StatusResponse{
+		QueueDepth:    status.QueueDepth,
+		QueueCapacity: status.QueueCapacity,
+		Workers:       status.Workers,
+		BusyWorkers:   status.BusyWorkers,
+		Registered:    status.Registered,
+	}
+}
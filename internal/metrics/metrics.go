@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "crack_hash_worker"
+
+type Metrics struct {
+	registry          *prometheus.Registry
+	candidatesHashed  *prometheus.CounterVec
+	tasksReceived     prometheus.Counter
+	tasksFinished     *prometheus.CounterVec
+	partsDispatched   prometheus.Counter
+	partsFinished     *prometheus.CounterVec
+	completionReports *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		registry: registry,
+		candidatesHashed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "candidates_hashed_total",
+			Help:      "Number of candidates hashed.",
+		}, []string{"algorithm"}),
+		tasksReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_received_total",
+			Help:      "Number of tasks accepted into the queue.",
+		}),
+		tasksFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_finished_total",
+			Help:      "Number of tasks finished, by reported status.",
+		}, []string{"status"}),
+		partsDispatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parts_dispatched_total",
+			Help:      "Number of parts handed to worker goroutines.",
+		}),
+		partsFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parts_finished_total",
+			Help:      "Number of parts finished, by final state.",
+		}, []string{"state"}),
+		completionReports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "completion_reports_total",
+			Help:      "Number of completion report attempts, by result.",
+		}, []string{"result"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.candidatesHashed,
+		m.tasksReceived,
+		m.tasksFinished,
+		m.partsDispatched,
+		m.partsFinished,
+		m.completionReports,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) WatchStatus(status func() model.WorkerStatus) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Number of tasks waiting in the queue.",
+		}, func() float64 {
+			return float64(status().QueueDepth)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_capacity",
+			Help:      "Capacity of the task queue.",
+		}, func() float64 {
+			return float64(status().QueueCapacity)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "goroutines",
+			Help:      "Number of cracking goroutines in the pool.",
+		}, func() float64 {
+			return float64(status().Workers)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "busy_goroutines",
+			Help:      "Number of cracking goroutines currently processing a part.",
+		}, func() float64 {
+			return float64(status().BusyWorkers)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "registered",
+			Help:      "Whether the worker is registered with the manager.",
+		}, func() float64 {
+			if status().Registered {
+				return 1
+			}
+			return 0
+		}),
+	)
+}
+
+func (m *Metrics) WatchHashRates(rates func() map[string]float64) {
+	m.registry.MustRegister(&hashRateCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "hashes_per_second"),
+			"Current hashing rate of running parts, by algorithm.",
+			[]string{"algorithm"},
+			nil,
+		),
+		rates: rates,
+	})
+}
+
+func (m *Metrics) CandidatesHashed(algorithm string, count uint64) {
+	m.candidatesHashed.WithLabelValues(algorithm).Add(float64(count))
+}
+
+func (m *Metrics) TaskReceived() {
+	m.tasksReceived.Inc()
+}
+
+func (m *Metrics) TaskFinished(status string) {
+	m.tasksFinished.WithLabelValues(status).Inc()
+}
+
+func (m *Metrics) PartDispatched() {
+	m.partsDispatched.Inc()
+}
+
+func (m *Metrics) PartFinished(state string) {
+	m.partsFinished.WithLabelValues(state).Inc()
+}
+
+func (m *Metrics) CompletionReported(err error) {
+	if err != nil {
+		m.completionReports.WithLabelValues("failure").Inc()
+		return
+	}
+
+	m.completionReports.WithLabelValues("success").Inc()
+}
+
+type hashRateCollector struct {
+	desc  *prometheus.Desc
+	rates func() map[string]float64
+}
+
+func (c *hashRateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *hashRateCollector) Collect(ch chan<- prometheus.Metric) {
+	for algorithm, rate := range c.rates() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, rate, algorithm)
+	}
+}
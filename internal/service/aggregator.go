@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/slogattr"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type partResult struct {
+	run   *taskRun
+	index int
+	part  model.CompletedPart
+}
+
+type taskReport struct {
+	run    *taskRun
+	span   trace.SpanContext
+	result model.TaskResult
+}
+
+type aggregation struct {
+	run       *taskRun
+	parts     map[int]model.Range
+	done      map[int]bool
+	sealed    bool
+	complete  bool
+	matches   map[string][]string
+	covered   []model.Range
+	errors    []error
+	reported  bool
+	updatedAt time.Time
+}
+
+type aggregator struct {
+	mu           sync.Mutex
+	log          *slog.Logger
+	registry     *taskRegistry
+	ttl          time.Duration
+	now          func() time.Time
+	aggregations map[*taskRun]*aggregation
+}
+
+func newAggregator(log *slog.Logger, registry *taskRegistry, ttl time.Duration) *aggregator {
+	return &aggregator{
+		log:          log,
+		registry:     registry,
+		ttl:          ttl,
+		now:          time.Now,
+		aggregations: make(map[*taskRun]*aggregation),
+	}
+}
+
+func (a *aggregator) begin(run *taskRun) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.aggregations[run] = a.newAggregation(run)
+}
+
+func (a *aggregator) conclude(run *taskRun, status string) []taskReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.finish(a.newAggregation(run), status)
+}
+
+func (a *aggregator) newAggregation(run *taskRun) *aggregation {
+	matches := make(map[string][]string)
+	for hash, words := range run.resume.matches {
+		matches[hash] = slices.Clone(words)
+	}
+
+	return &aggregation{
+		run:       run,
+		parts:     make(map[int]model.Range),
+		done:      make(map[int]bool),
+		matches:   matches,
+		covered:   slices.Clone(run.resume.covered),
+		errors:    make([]error, 0),
+		updatedAt: a.now(),
+	}
+}
+
+func (a *aggregator) dispatch(run *taskRun, index int, part model.Part) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	value, ok := a.aggregations[run]
+	if !ok {
+		return false
+	}
+
+	value.parts[index] = model.Range{Start: part.Start, End: part.End}
+	value.updatedAt = a.now()
+
+	return true
+}
+
+func (a *aggregator) seal(run *taskRun, complete bool) []taskReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	value, ok := a.aggregations[run]
+	if !ok {
+		return nil
+	}
+
+	value.sealed = true
+	value.complete = complete
+	value.updatedAt = a.now()
+
+	return a.finishIfDone(value)
+}
+
+func (a *aggregator) add(result partResult) []taskReport {
+	const op = "service.aggregator.add"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("task_id", result.part.TaskId),
+		slog.Int("part", result.index),
+	)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	value, ok := a.aggregations[result.run]
+	if !ok {
+		log.Warn("dropping result of unknown or expired task")
+		return nil
+	}
+
+	expected, ok := value.parts[result.index]
+	if !ok {
+		log.Error("dropping result of part that was not dispatched")
+		return nil
+	}
+
+	if value.done[result.index] {
+		log.Warn("dropping duplicate result of part")
+		return nil
+	}
+
+	part := result.part
+	if part.Start != expected.Start || part.End != expected.End {
+		log.Error("dropping result with unexpected range", slog.Any("expected", expected), slog.Any("part", part))
+		return nil
+	}
+
+	value.done[result.index] = true
+	value.updatedAt = a.now()
+
+	if errors.Is(part.Error, model.ErrMatchFound) {
+		log.Info("part stopped: match found by another part")
+		part.Error = nil
+	}
+
+	for hash, words := range part.Matches {
+		value.matches[hash] = append(value.matches[hash], words...)
+	}
+
+	if part.Reached > part.Start {
+		value.covered = append(value.covered, model.Range{Start: part.Start, End: part.Reached})
+	}
+
+	if part.Error != nil && !errors.Is(part.Error, model.ErrTaskCancelled) {
+		log.Error("error during computation", slogattr.Err(part.Error), slog.Uint64("reached", part.Reached))
+		value.errors = append(value.errors, part.Error)
+	}
+
+	reports := make([]taskReport, 0)
+
+	if !value.reported && part.Error == nil && len(part.Matches) > 0 && value.run.task.StopOnFirstMatch {
+		log.Info("first match found")
+
+		task := value.run.task
+		covered := mergeRanges(value.covered)
+		uncovered := complementRanges(model.Range{Start: task.Start, End: task.End}, covered)
+		reports = append(reports, a.makeResult(value, model.TaskStatusFound, compactMatches(value.matches), covered, uncovered))
+		value.reported = true
+	}
+
+	return append(reports, a.finishIfDone(value)...)
+}
+
+func (a *aggregator) expire() []taskReport {
+	const op = "service.aggregator.expire"
+
+	if a.ttl <= 0 {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	reports := make([]taskReport, 0)
+
+	for run, value := range a.aggregations {
+		if now.Sub(value.updatedAt) < a.ttl {
+			continue
+		}
+
+		a.log.Warn(
+			"aggregation expired",
+			slog.String("op", op),
+			slog.String("task_id", run.task.TaskId),
+			slog.Int("dispatched", len(value.parts)),
+			slog.Int("done", len(value.done)),
+		)
+
+		run.cancel(model.ErrTaskExpired)
+		reports = append(reports, a.finish(value, model.TaskStatusExpired)...)
+	}
+
+	return reports
+}
+
+func (a *aggregator) finishIfDone(value *aggregation) []taskReport {
+	if !value.sealed || len(value.done) < len(value.parts) {
+		return nil
+	}
+
+	status := model.TaskStatusCompleted
+	if errors.Is(context.Cause(value.run.ctx), model.ErrTaskCancelled) {
+		status = model.TaskStatusCancelled
+	}
+
+	ranges := slices.Clone(value.run.resume.covered)
+	for _, r := range value.parts {
+		ranges = append(ranges, r)
+	}
+
+	if err := verifyTiling(value.run.task, ranges, value.complete); err != nil {
+		a.log.Error("dispatched parts do not tile task range", slog.String("task_id", value.run.task.TaskId), slogattr.Err(err))
+		value.errors = append(value.errors, err)
+	}
+
+	return a.finish(value, status)
+}
+
+func (a *aggregator) finish(value *aggregation, status string) []taskReport {
+	delete(a.aggregations, value.run)
+
+	if value.reported {
+		a.registry.finish(value.run, model.TaskStatusFound)
+		endTaskSpan(value.run, model.TaskStatusFound)
+		return nil
+	}
+
+	task := value.run.task
+
+	covered := mergeRanges(value.covered)
+	if err := verifyDisjoint(value.covered); err != nil {
+		a.log.Error("covered ranges overlap", slog.String("task_id", task.TaskId), slogattr.Err(err))
+	}
+
+	uncovered := complementRanges(model.Range{Start: task.Start, End: task.End}, covered)
+
+	if status == model.TaskStatusCompleted && (len(uncovered) > 0 || len(value.errors) > 0) {
+		a.log.Warn("task partially completed", slog.String("task_id", task.TaskId), slog.Any("uncovered", uncovered), slog.Any("errors", value.errors))
+		status = model.TaskStatusPartial
+	}
+
+	a.registry.finish(value.run, status)
+	endTaskSpan(value.run, status)
+
+	return []taskReport{a.makeResult(value, status, compactMatches(value.matches), covered, uncovered)}
+}
+
+func compactMatches(matches map[string][]string) map[string][]string {
+	compacted := make(map[string][]string, len(matches))
+	for hash, words := range matches {
+		words = slices.Clone(words)
+		slices.Sort(words)
+		compacted[hash] = slices.Compact(words)
+	}
+
+	return compacted
+}
+
+func (a *aggregator) makeResult(value *aggregation, status string, matches map[string][]string, covered, uncovered []model.Range) taskReport {
+	task := value.run.task
+
+	return taskReport{
+		run:  value.run,
+		span: value.run.span.SpanContext(),
+		result: model.TaskResult{
+			RequestId: task.RequestId,
+			TaskId:    task.TaskId,
+			Status:    status,
+			Start:     task.Start,
+			End:       task.End,
+			Matches:   matches,
+			Covered:   covered,
+			Uncovered: uncovered,
+		},
+	}
+}
+
+func verifyTiling(task model.Task, ranges []model.Range, complete bool) error {
+	sortRanges(ranges)
+
+	next := task.Start
+	for _, r := range ranges {
+		if r.Start < next {
+			return fmt.Errorf("part [%d, %d) overlaps previous part ending at %d", r.Start, r.End, next)
+		}
+		if complete && r.Start > next {
+			return fmt.Errorf("gap [%d, %d) between parts", next, r.Start)
+		}
+		next = r.End
+	}
+
+	if complete && next != task.End {
+		return fmt.Errorf("parts end at %d instead of %d", next, task.End)
+	}
+
+	return nil
+}
+
+func verifyDisjoint(ranges []model.Range) error {
+	sorted := slices.Clone(ranges)
+	sortRanges(sorted)
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start < sorted[i-1].End {
+			return fmt.Errorf("range [%d, %d) overlaps [%d, %d)", sorted[i].Start, sorted[i].End, sorted[i-1].Start, sorted[i-1].End)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestAggregator(ttl time.Duration) (*aggregator, *taskRegistry) {
+	registry := newTaskRegistry()
+	return newAggregator(slog.New(slog.NewTextHandler(io.Discard, nil)), registry, ttl), registry
+}
+
+func completePart(run *taskRun, index int, part model.Part, reached uint64, matches map[string][]string) partResult {
+	return partResult{
+		run:   run,
+		index: index,
+		part: model.CompletedPart{
+			RequestId: part.RequestId,
+			TaskId:    part.TaskId,
+			Matches:   matches,
+			Start:     part.Start,
+			End:       part.End,
+			Reached:   reached,
+		},
+	}
+}
+
+func TestAggregator_Completed(t *testing.T) {
+	agg, registry := newTestAggregator(time.Minute)
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "task", Start: 0, End: 100})
+
+	parts := []model.Part{makePart(run.task, 0, 30), makePart(run.task, 30, 60), makePart(run.task, 60, 100)}
+	agg.begin(run)
+	for i, part := range parts {
+		assert.True(t, agg.dispatch(run, i, part))
+	}
+	assert.Empty(t, agg.seal(run, true))
+
+	assert.Empty(t, agg.add(completePart(run, 0, parts[0], parts[0].End, map[string][]string{"h": {"a"}})))
+	assert.Empty(t, agg.add(completePart(run, 0, parts[0], parts[0].End, nil)), "duplicate must be dropped")
+	assert.Empty(t, agg.add(completePart(run, 1, parts[1], parts[1].End, nil)))
+
+	reports := agg.add(completePart(run, 2, parts[2], parts[2].End, map[string][]string{"h": {"b"}}))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, model.TaskStatusCompleted, reports[0].result.Status)
+	assert.Equal(t, []model.Range{{Start: 0, End: 100}}, reports[0].result.Covered)
+	assert.Empty(t, reports[0].result.Uncovered)
+	assert.ElementsMatch(t, []string{"a", "b"}, reports[0].result.Matches["h"])
+
+	_, ok := registry.get("task")
+	assert.False(t, ok)
+
+	fingerprint, progress, ok := registry.existing("task")
+	assert.True(t, ok)
+	assert.Equal(t, taskFingerprint(run.task), fingerprint)
+	assert.Equal(t, model.TaskStatusCompleted, progress.State)
+	assert.Equal(t, []model.TaskProgress{progress}, registry.tasks())
+
+	other := run.task
+	other.End = 200
+	assert.NotEqual(t, fingerprint, taskFingerprint(other))
+}
+
+func TestAggregator_UnexpectedParts(t *testing.T) {
+	agg, registry := newTestAggregator(time.Minute)
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "task", Start: 0, End: 10})
+
+	part := model.Part{TaskId: "task", Start: 0, End: 10}
+	agg.begin(run)
+	agg.dispatch(run, 0, part)
+	agg.seal(run, true)
+
+	assert.Empty(t, agg.add(completePart(run, 1, part, part.End, nil)), "undispatched index must be dropped")
+
+	other := model.Part{TaskId: "task", Start: 0, End: 5}
+	assert.Empty(t, agg.add(completePart(run, 0, other, other.End, nil)), "unexpected range must be dropped")
+
+	reports := agg.add(completePart(run, 0, part, 4, nil))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, model.TaskStatusPartial, reports[0].result.Status)
+	assert.Equal(t, []model.Range{{Start: 0, End: 4}}, reports[0].result.Covered)
+	assert.Equal(t, []model.Range{{Start: 4, End: 10}}, reports[0].result.Uncovered)
+}
+
+func TestAggregator_Gap(t *testing.T) {
+	agg, registry := newTestAggregator(time.Minute)
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "task", Start: 0, End: 10})
+
+	part := model.Part{TaskId: "task", Start: 0, End: 8}
+	agg.begin(run)
+	agg.dispatch(run, 0, part)
+	agg.seal(run, true)
+
+	reports := agg.add(completePart(run, 0, part, part.End, nil))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, model.TaskStatusPartial, reports[0].result.Status)
+	assert.Equal(t, []model.Range{{Start: 8, End: 10}}, reports[0].result.Uncovered)
+}
+
+func TestAggregator_Expire(t *testing.T) {
+	agg, registry := newTestAggregator(time.Minute)
+	now := time.Now()
+	agg.now = func() time.Time { return now }
+
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "task", Start: 0, End: 10})
+	parts := []model.Part{makePart(run.task, 0, 5), makePart(run.task, 5, 10)}
+	agg.begin(run)
+	for i, part := range parts {
+		agg.dispatch(run, i, part)
+	}
+	agg.seal(run, true)
+	agg.add(completePart(run, 0, parts[0], parts[0].End, nil))
+
+	assert.Empty(t, agg.expire())
+
+	now = now.Add(time.Minute)
+	reports := agg.expire()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, model.TaskStatusExpired, reports[0].result.Status)
+	assert.Equal(t, []model.Range{{Start: parts[1].Start, End: parts[1].End}}, reports[0].result.Uncovered)
+	assert.ErrorIs(t, run.ctx.Err(), context.Canceled)
+
+	assert.Empty(t, agg.add(completePart(run, 1, parts[1], parts[1].End, nil)), "late result must be dropped")
+}
+
+func TestRanges(t *testing.T) {
+	merged := mergeRanges([]model.Range{{Start: 5, End: 7}, {Start: 0, End: 2}, {Start: 2, End: 3}, {Start: 6, End: 9}, {Start: 4, End: 4}})
+	assert.Equal(t, []model.Range{{Start: 0, End: 3}, {Start: 5, End: 9}}, merged)
+
+	complement := complementRanges(model.Range{Start: 0, End: 12}, merged)
+	assert.Equal(t, []model.Range{{Start: 3, End: 5}, {Start: 9, End: 12}}, complement)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"unicode/utf8"
+)
+
+func decodeAlphabet(alphabet, encoding string) ([][]byte, error) {
+	switch encoding {
+	case model.AlphabetEncodingUTF8:
+		return decodeUTF8Alphabet(alphabet)
+	case model.AlphabetEncodingHex:
+		return decodeHexAlphabet(alphabet)
+	default:
+		return nil, fmt.Errorf("unknown alphabet encoding %q: %w", encoding, model.ErrInvalidAlphabet)
+	}
+}
+
+func decodeUTF8Alphabet(alphabet string) ([][]byte, error) {
+	if !utf8.ValidString(alphabet) {
+		return nil, fmt.Errorf("alphabet is not valid utf-8: %w", model.ErrInvalidAlphabet)
+	}
+
+	symbols := make([][]byte, 0, utf8.RuneCountInString(alphabet))
+	visited := make(map[rune]bool)
+
+	for _, r := range alphabet {
+		if visited[r] {
+			return nil, fmt.Errorf("alphabet contains duplicate symbol %q: %w", r, model.ErrInvalidAlphabet)
+		}
+
+		visited[r] = true
+		symbols = append(symbols, utf8.AppendRune(nil, r))
+	}
+
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("alphabet is empty: %w", model.ErrInvalidAlphabet)
+	}
+
+	return symbols, nil
+}
+
+func decodeHexAlphabet(alphabet string) ([][]byte, error) {
+	decoded, err := hex.DecodeString(alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("alphabet is not valid hex: %w", model.ErrInvalidAlphabet)
+	}
+
+	symbols := make([][]byte, 0, len(decoded))
+	visited := make(map[byte]bool)
+
+	for _, b := range decoded {
+		if visited[b] {
+			return nil, fmt.Errorf("alphabet contains duplicate byte %#02x: %w", b, model.ErrInvalidAlphabet)
+		}
+
+		visited[b] = true
+		symbols = append(symbols, []byte{b})
+	}
+
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("alphabet is empty: %w", model.ErrInvalidAlphabet)
+	}
+
+	return symbols, nil
+}
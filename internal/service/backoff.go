@@ -0,0 +1,17 @@
+package service
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+func backoff(minBackoff, maxBackoff time.Duration, attempts int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
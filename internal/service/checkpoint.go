@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/slogattr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const checkpointSuffix = ".checkpoint.json"
+
+type checkpoint struct {
+	Task    model.Task          `json:"task"`
+	Covered []model.Range       `json:"covered"`
+	Matches map[string][]string `json:"matches"`
+}
+
+type resumeState struct {
+	covered []model.Range
+	matches map[string][]string
+}
+
+func (r *taskRun) checkpoint() checkpoint {
+	covered, matches := r.progress.checkpoint()
+
+	covered = mergeRanges(append(slices.Clone(r.resume.covered), covered...))
+	for hash, words := range r.resume.matches {
+		matches[hash] = append(matches[hash], words...)
+	}
+
+	return checkpoint{
+		Task:    r.task,
+		Covered: covered,
+		Matches: matches,
+	}
+}
+
+type CheckpointStore struct {
+	log *slog.Logger
+	dir string
+	mu  sync.Mutex
+}
+
+func NewCheckpointStore(log *slog.Logger, dir string) (*CheckpointStore, error) {
+	const op = "service.NewCheckpointStore"
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: error creating state directory %q: %w", op, dir, err)
+	}
+
+	return &CheckpointStore{
+		log: log,
+		dir: dir,
+	}, nil
+}
+
+func (s *CheckpointStore) save(run *taskRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run.checkpointDone {
+		return nil
+	}
+
+	return writeFileAtomic(s.path(run.task.TaskId), run.checkpoint())
+}
+
+func (s *CheckpointStore) remove(run *taskRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run.checkpointDone = true
+	s.removeFile(run.task.TaskId)
+}
+
+func (s *CheckpointStore) removeFile(taskId string) {
+	if err := os.Remove(s.path(taskId)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Error("error removing checkpoint", slog.String("task_id", taskId), slogattr.Err(err))
+	}
+}
+
+func (s *CheckpointStore) load() ([]checkpoint, error) {
+	const op = "service.CheckpointStore.load"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading state directory %q: %w", op, s.dir, err)
+	}
+
+	checkpoints := make([]checkpoint, 0)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(s.dir, name)
+
+		if strings.HasSuffix(name, tempFileSuffix) {
+			_ = os.Remove(path)
+			continue
+		}
+
+		if !strings.HasSuffix(name, checkpointSuffix) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: error reading checkpoint %q: %w", op, path, err)
+		}
+
+		var value checkpoint
+		if err := json.Unmarshal(data, &value); err != nil || value.Task.TaskId == "" {
+			s.log.Error("dropping corrupted checkpoint", slog.String("path", path), slogattr.Err(err))
+			_ = os.Remove(path)
+			continue
+		}
+
+		checkpoints = append(checkpoints, value)
+	}
+
+	return checkpoints, nil
+}
+
+func (s *CheckpointStore) path(taskId string) string {
+	return filepath.Join(s.dir, encodeTaskId(taskId)+checkpointSuffix)
+}
+
+func checkpointer(
+	log *slog.Logger,
+	store *CheckpointStore,
+	registry *taskRegistry,
+	interval time.Duration,
+	stop <-chan struct{},
+	done chan<- struct{},
+) {
+	const op = "service.checkpointer"
+
+	log = log.With(
+		slog.String("op", op),
+	)
+
+	defer close(done)
+
+	if interval <= 0 {
+		log.Warn("periodic checkpoints disabled")
+		<-stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, run := range registry.list() {
+			if run.ctx.Err() != nil {
+				continue
+			}
+
+			if err := store.save(run); err != nil {
+				log.Error("error saving checkpoint", slog.String("task_id", run.task.TaskId), slogattr.Err(err))
+			}
+		}
+	}
+}
+
+func resume(
+	log *slog.Logger,
+	store *CheckpointStore,
+	outbox *Outbox,
+	registry *taskRegistry,
+	aggregator *aggregator,
+	queue chan<- *taskRun,
+	reports chan<- taskReport,
+) {
+	const op = "service.resume"
+
+	log = log.With(
+		slog.String("op", op),
+	)
+
+	checkpoints, err := store.load()
+	if err != nil {
+		log.Error("error loading checkpoints", slogattr.Err(err))
+		return
+	}
+
+	for _, value := range checkpoints {
+		task := value.Task
+		log := log.With(slog.String("task_id", task.TaskId))
+
+		if _, ok := outbox.report(task.TaskId); ok {
+			log.Info("task already reported, dropping checkpoint")
+			store.removeFile(task.TaskId)
+			continue
+		}
+
+		ctx, _ := tracer.Start(context.Background(), "task", trace.WithAttributes(
+			append(taskAttributes(task), attribute.Bool("task.resumed", true))...,
+		))
+
+		run, ok := registry.add(ctx, task)
+		if !ok {
+			log.Warn("task already exists, dropping checkpoint")
+			continue
+		}
+
+		run.resume = resumeState{
+			covered: mergeRanges(value.Covered),
+			matches: value.Matches,
+		}
+		run.progress.resume(run.resume.covered)
+
+		if task.StopOnFirstMatch && len(value.Matches) > 0 {
+			log.Info("match found before restart, reporting task from checkpoint")
+			for _, report := range aggregator.conclude(run, model.TaskStatusFound) {
+				reports <- report
+			}
+			continue
+		}
+
+		select {
+		case queue <- run:
+			log.Info("resuming task from checkpoint", slog.Any("covered", run.resume.covered))
+		default:
+			log.Warn("queue is full, reporting task from checkpoint")
+			for _, report := range aggregator.conclude(run, model.TaskStatusPartial) {
+				reports <- report
+			}
+		}
+	}
+}
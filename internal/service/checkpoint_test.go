@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointStore_SaveLoadRemove(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+
+	store, err := NewCheckpointStore(log, dir)
+	assert.NoError(t, err)
+
+	registry := newTaskRegistry()
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "t/1", Start: 0, End: 100})
+	run.resume = resumeState{covered: []model.Range{{Start: 60, End: 80}}, matches: map[string][]string{"h": {"a"}}}
+
+	first := run.progress.dispatch(0, makePart(run.task, 0, 30))
+	first.update(30)
+	first.match("h", "b")
+	second := run.progress.dispatch(1, makePart(run.task, 30, 60))
+	second.update(10)
+
+	assert.NoError(t, store.save(run))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+checkpointSuffix), []byte("{"), 0o644))
+
+	checkpoints, err := store.load()
+	assert.NoError(t, err)
+	assert.Len(t, checkpoints, 1)
+	assert.Equal(t, run.task, checkpoints[0].Task)
+	assert.Equal(t, []model.Range{{Start: 0, End: 40}, {Start: 60, End: 80}}, checkpoints[0].Covered)
+	assert.ElementsMatch(t, []string{"a", "b"}, checkpoints[0].Matches["h"])
+
+	store.remove(run)
+	assert.NoError(t, store.save(run))
+
+	checkpoints, err = store.load()
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoints)
+}
+
+func TestAggregator_Resumed(t *testing.T) {
+	agg, registry := newTestAggregator(time.Minute)
+	run, _ := registry.add(context.Background(), model.Task{TaskId: "task", Start: 0, End: 100})
+	run.resume = resumeState{covered: []model.Range{{Start: 0, End: 40}}, matches: map[string][]string{"h": {"a"}}}
+
+	segments := complementRanges(model.Range{Start: run.task.Start, End: run.task.End}, run.resume.covered)
+	assert.Equal(t, []model.Range{{Start: 40, End: 100}}, segments)
+
+	part := makePart(run.task, 40, 100)
+	agg.begin(run)
+	assert.True(t, agg.dispatch(run, 0, part))
+	assert.Empty(t, agg.seal(run, true))
+
+	reports := agg.add(completePart(run, 0, part, part.End, map[string][]string{"h": {"a", "b"}}))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, model.TaskStatusCompleted, reports[0].result.Status)
+	assert.Equal(t, []model.Range{{Start: 0, End: 100}}, reports[0].result.Covered)
+	assert.Equal(t, []string{"a", "b"}, reports[0].result.Matches["h"])
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	minChunkSize        = cancellationCheckInterval
+	throughputSmoothing = 0.3
+)
+
+type throughput struct {
+	mu   sync.Mutex
+	rate float64
+}
+
+func (t *throughput) observe(candidates uint64, elapsed time.Duration) {
+	if candidates == 0 || elapsed <= 0 {
+		return
+	}
+
+	rate := float64(candidates) / elapsed.Seconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate == 0 {
+		t.rate = rate
+		return
+	}
+
+	t.rate = throughputSmoothing*rate + (1-throughputSmoothing)*t.rate
+}
+
+func (t *throughput) perSecond() (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.rate, t.rate > 0
+}
+
+type chunker struct {
+	task          model.Task
+	segments      []model.Range
+	remaining     uint64
+	workersCount  uint64
+	initialSize   uint64
+	chunkDuration time.Duration
+	throughput    *throughput
+}
+
+func newChunker(
+	task model.Task,
+	segments []model.Range,
+	workersCount uint64,
+	initialSize uint64,
+	chunkDuration time.Duration,
+	throughput *throughput,
+) *chunker {
+	remaining := uint64(0)
+	for _, segment := range segments {
+		remaining += segment.End - segment.Start
+	}
+
+	return &chunker{
+		task:          task,
+		segments:      slices.Clone(segments),
+		remaining:     remaining,
+		workersCount:  max(workersCount, 1),
+		initialSize:   max(initialSize, minChunkSize),
+		chunkDuration: chunkDuration,
+		throughput:    throughput,
+	}
+}
+
+func (c *chunker) HasNext() bool {
+	return len(c.segments) > 0
+}
+
+func (c *chunker) Next() model.Part {
+	segment := &c.segments[0]
+	size := min(c.chunkSize(), segment.End-segment.Start)
+
+	part := makePart(c.task, segment.Start, segment.Start+size)
+	segment.Start += size
+	c.remaining -= size
+
+	if segment.Start == segment.End {
+		c.segments = c.segments[1:]
+	}
+
+	return part
+}
+
+func (c *chunker) chunkSize() uint64 {
+	size := c.initialSize
+	if rate, ok := c.throughput.perSecond(); ok && c.chunkDuration > 0 {
+		size = uint64(rate * c.chunkDuration.Seconds())
+	}
+
+	fairShare := c.remaining / c.workersCount
+	if c.remaining%c.workersCount != 0 {
+		fairShare++
+	}
+
+	return max(min(size, fairShare), minChunkSize)
+}
+
+func makePart(task model.Task, start, end uint64) model.Part {
+	return model.Part{
+		RequestId:        task.RequestId,
+		TaskId:           task.TaskId,
+		Mode:             task.Mode,
+		Mask:             task.Mask,
+		CustomCharsets:   task.CustomCharsets,
+		Wordlist:         task.Wordlist,
+		Rules:            task.Rules,
+		StopOnFirstMatch: task.StopOnFirstMatch,
+		Alphabet:         task.Alphabet,
+		AlphabetEncoding: task.AlphabetEncoding,
+		Algorithm:        task.Algorithm,
+		Hashes:           task.Hashes,
+		MaxLength:        task.MaxLength,
+		Start:            start,
+		End:              end,
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChunker(t *testing.T) {
+	task := model.Task{Start: 10, End: 1_000_000}
+
+	rate := new(throughput)
+	chunks := newChunker(task, []model.Range{{Start: task.Start, End: task.End}}, 4, 4096, 100*time.Millisecond, rate)
+
+	first := chunks.Next()
+	assert.Equal(t, uint64(10), first.Start)
+	assert.Equal(t, uint64(10+4096), first.End)
+
+	rate.observe(200_000, time.Second)
+	second := chunks.Next()
+	assert.Equal(t, first.End, second.Start)
+	assert.Equal(t, uint64(20_000), second.End-second.Start)
+
+	next := second.End
+	for chunks.HasNext() {
+		part := chunks.Next()
+		assert.Equal(t, next, part.Start)
+		assert.LessOrEqual(t, part.End, task.End)
+		next = part.End
+	}
+	assert.Equal(t, task.End, next)
+}
+
+func TestChunker_Segments(t *testing.T) {
+	task := model.Task{Start: 0, End: 100_000}
+	segments := []model.Range{{Start: 0, End: 10}, {Start: 50_000, End: 60_000}}
+
+	chunks := newChunker(task, segments, 4, 4096, time.Second, new(throughput))
+
+	parts := make([]model.Range, 0)
+	for chunks.HasNext() {
+		part := chunks.Next()
+		parts = append(parts, model.Range{Start: part.Start, End: part.End})
+	}
+
+	assert.Equal(t, segments, mergeRanges(parts))
+}
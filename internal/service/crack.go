@@ -2,218 +2,707 @@ package service
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"fmt"
 	"github.com/fatalistix/crack-hash-worker/internal/config"
 	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/crack-hash-worker/internal/hasher"
 	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/crack-hash-worker/internal/metrics"
 	"github.com/fatalistix/slogattr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"log/slog"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type CrackService struct {
-	wg           *sync.WaitGroup
-	parts        chan<- model.Part
-	results      chan<- model.CompletedPart
-	workersCount uint64
-	log          *slog.Logger
+	wg             *sync.WaitGroup
+	wordlists      *WordlistStore
+	queue          chan *taskRun
+	skipped        *atomic.Int64
+	registry       *taskRegistry
+	aggregator     *aggregator
+	registration   *Registration
+	outbox         *Outbox
+	checkpoints    *CheckpointStore
+	dispatcherDone <-chan struct{}
+	reporterDone   <-chan struct{}
+	checkpointStop chan<- struct{}
+	checkpointDone <-chan struct{}
+	parts          chan<- partJob
+	results        chan<- partResult
+	workersCount   uint64
+	busyWorkers    *atomic.Uint64
+	busySince      []atomic.Int64
+	chunkTimeout   time.Duration
+	draining       *atomic.Bool
+	drainOnce      sync.Once
+	drainErr       error
+	metrics        *metrics.Metrics
+	mu             sync.RWMutex
+	closed         bool
+	log            *slog.Logger
 }
 
 func NewCrackService(
 	log *slog.Logger,
-	managerConfig config.ManagerConfig,
 	workerConfig config.WorkerConfig,
-	workerId string,
+	registration *Registration,
+	outbox *Outbox,
+	checkpoints *CheckpointStore,
+	metrics *metrics.Metrics,
 ) *CrackService {
 	wg := new(sync.WaitGroup)
-	parts := make(chan model.Part)
-	results := make(chan model.CompletedPart)
+	queue := make(chan *taskRun, workerConfig.QueueSize)
+	skipped := new(atomic.Int64)
+	registry := newTaskRegistry()
+	aggregator := newAggregator(log, registry, workerConfig.AggregationTTL)
+	dispatcherDone := make(chan struct{})
+	reporterDone := make(chan struct{})
+	checkpointStop := make(chan struct{})
+	checkpointDone := make(chan struct{})
+	parts := make(chan partJob)
+	results := make(chan partResult)
+	reports := make(chan taskReport)
+	wordlists := NewWordlistStore(log, workerConfig.WordlistDir, workerConfig.WordlistIndexDir)
+	busyWorkers := new(atomic.Uint64)
+	busySince := make([]atomic.Int64, workerConfig.GoroutineCount)
+	draining := new(atomic.Bool)
 
 	for i := uint64(0); i < workerConfig.GoroutineCount; i++ {
 		wg.Add(1)
 		logWithGoroutineId := log.With(slog.Uint64("goroutine worker id", i))
-		go worker(logWithGoroutineId, parts, results, workerConfig.SubTaskTimeout, wg)
+		go worker(logWithGoroutineId, wordlists, parts, results, workerConfig.ChunkTimeout, busyWorkers, &busySince[i], metrics, wg)
 	}
 
 	log.Info("worker pool created", slog.Uint64("workers count", workerConfig.GoroutineCount))
 
-	go resultHandler(log, managerConfig.Address, workerId, results, workerConfig.GoroutineCount)
+	go wordlists.Preload()
+
+	go dispatcher(log, aggregator, queue, skipped, parts, reports, workerConfig, draining, metrics, dispatcherDone)
+
+	log.Info("dispatcher started", slog.Uint64("queue size", workerConfig.QueueSize))
+
+	go resultHandler(log, aggregator, results, reports, workerConfig.AggregationTTL)
+	go reporter(log, outbox, checkpoints, reports, metrics, reporterDone)
 
 	log.Info("result handler started")
 
-	return &CrackService{
-		wg:           wg,
-		parts:        parts,
-		results:      results,
-		workersCount: workerConfig.GoroutineCount,
-		log:          log,
+	go checkpointer(log, checkpoints, registry, workerConfig.CheckpointInterval, checkpointStop, checkpointDone)
+
+	log.Info("checkpointer started", slog.Duration("interval", workerConfig.CheckpointInterval))
+
+	s := &CrackService{
+		wg:             wg,
+		wordlists:      wordlists,
+		queue:          queue,
+		skipped:        skipped,
+		registry:       registry,
+		aggregator:     aggregator,
+		registration:   registration,
+		outbox:         outbox,
+		checkpoints:    checkpoints,
+		dispatcherDone: dispatcherDone,
+		reporterDone:   reporterDone,
+		checkpointStop: checkpointStop,
+		checkpointDone: checkpointDone,
+		parts:          parts,
+		results:        results,
+		workersCount:   workerConfig.GoroutineCount,
+		busyWorkers:    busyWorkers,
+		busySince:      busySince,
+		chunkTimeout:   workerConfig.ChunkTimeout,
+		draining:       draining,
+		metrics:        metrics,
+		log:            log,
 	}
+
+	resume(log, checkpoints, outbox, registry, aggregator, queue, reports)
+
+	return s
 }
 
-func worker(log *slog.Logger, parts <-chan model.Part, results chan<- model.CompletedPart, subTaskTimeout time.Duration, wg *sync.WaitGroup) {
+func worker(
+	log *slog.Logger,
+	wordlists *WordlistStore,
+	parts <-chan partJob,
+	results chan<- partResult,
+	chunkTimeout time.Duration,
+	busyWorkers *atomic.Uint64,
+	busySince *atomic.Int64,
+	metrics *metrics.Metrics,
+	wg *sync.WaitGroup,
+) {
 	defer wg.Done()
-	for part := range parts {
-		log.Info("worker is processing part", slog.Any("part", part))
-		handlePart(log, part, results, subTaskTimeout)
+	for job := range parts {
+		busyWorkers.Add(1)
+		busySince.Store(time.Now().UnixNano())
+		log.Info("worker is processing part", slog.Any("part", job.part))
+		handlePart(log, wordlists, job, results, chunkTimeout, metrics)
+		busySince.Store(0)
+		busyWorkers.Add(^uint64(0))
 	}
 }
 
-func resultHandler(log *slog.Logger, managerAddress, workerId string, results <-chan model.CompletedPart, workersCount uint64) {
-	const op = "service.resultHandler"
-
-	type partWithCount struct {
-		Part   model.CompletedPart
-		Count  uint64
-		Errors []error
-	}
+func dispatcher(
+	log *slog.Logger,
+	aggregator *aggregator,
+	queue <-chan *taskRun,
+	skipped *atomic.Int64,
+	parts chan<- partJob,
+	reports chan<- taskReport,
+	workerConfig config.WorkerConfig,
+	draining *atomic.Bool,
+	metrics *metrics.Metrics,
+	done chan<- struct{},
+) {
+	const op = "service.dispatcher"
 
 	log = log.With(
 		slog.String("op", op),
 	)
 
-	idToResults := make(map[string]partWithCount)
-
-	completer := client.NewCompleter(log)
-	for result := range results {
+	defer close(done)
 
-		log.Info("serving partial result", slog.Any("part", result))
-
-		value, ok := idToResults[result.TaskId]
-		if !ok {
-			errors := make([]error, 0)
-			if result.Error != nil {
-				log.Error("error during computation", slogattr.Err(result.Error))
-				errors = append(errors, result.Error)
-			}
-			value = partWithCount{Part: result, Count: 1, Errors: errors}
-			log.Info("first part of result", slog.String("task_id", result.TaskId))
-		} else {
-			value.Part.Start = min(result.Start, value.Part.Start)
-			value.Part.End = max(result.End, value.Part.End)
-			value.Part.Data = append(value.Part.Data, result.Data...)
-			if result.Error != nil {
-				log.Error("error during computation", slogattr.Err(result.Error))
-				value.Errors = append(value.Errors, result.Error)
-			}
-			value.Count++
-			log.Info("part of result", slog.String("task_id", result.TaskId))
+	for run := range queue {
+		if !run.claim() {
+			skipped.Add(-1)
+			log.Info("task cancelled while queued, skipping", slog.String("task_id", run.task.TaskId))
+			continue
 		}
 
-		if value.Count < workersCount {
-			idToResults[result.TaskId] = value
-			log.Info("current partial result", slog.Any("partial result", value.Part))
+		if run.ctx.Err() != nil {
+			log.Info("task stopped while queued, skipping", slog.String("task_id", run.task.TaskId), slogattr.Err(context.Cause(run.ctx)))
+			for _, report := range aggregator.conclude(run, model.TaskStatusPartial) {
+				reports <- report
+			}
 			continue
 		}
 
-		log.Info("full result", slog.String("task_id", result.TaskId))
+		chunks := newChunker(
+			run.task,
+			complementRanges(model.Range{Start: run.task.Start, End: run.task.End}, run.resume.covered),
+			workerConfig.GoroutineCount,
+			workerConfig.InitialChunkSize,
+			workerConfig.ChunkDuration,
+			run.throughput,
+		)
+
+		aggregator.begin(run)
+		run.progress.begin()
+
+		i := 0
+		for ; chunks.HasNext(); i++ {
+			if draining.Load() {
+				log.Info("worker is shutting down, dispatching no more chunks", slog.String("task_id", run.task.TaskId))
+				break
+			}
+
+			if run.ctx.Err() != nil {
+				log.Info("task stopped, dispatching no more chunks", slog.String("task_id", run.task.TaskId), slogattr.Err(context.Cause(run.ctx)))
+				break
+			}
+
+			part := chunks.Next()
+			if !aggregator.dispatch(run, i, part) {
+				log.Warn("task aggregation expired during dispatch", slog.String("task_id", run.task.TaskId))
+				break
+			}
 
-		delete(idToResults, result.TaskId)
+			progress := run.progress.dispatch(i, part)
+			metrics.PartDispatched()
 
-		if len(value.Errors) > 0 {
-			log.Error("errors during computation, result won't be sent", slog.Any("errors", value.Errors))
-			continue
+			parts <- partJob{run: run, index: i, part: part, progress: progress}
 		}
 
-		request := client.CompleteRequest{
-			RequestId: result.RequestId,
-			TaskId:    result.TaskId,
-			WorkerId:  workerId,
-			Start:     value.Part.Start,
-			End:       value.Part.End,
-			Data:      value.Part.Data,
-		}
-		if err := completer.Complete(managerAddress, request); err != nil {
-			log.Error("failed to complete", slogattr.Err(err))
+		log.Info("dispatched task", slog.String("task_id", run.task.TaskId), slog.Int("chunks", i))
+
+		for _, report := range aggregator.seal(run, !chunks.HasNext()) {
+			reports <- report
 		}
 	}
 }
 
-func handlePart(log *slog.Logger, part model.Part, results chan<- model.CompletedPart, subTaskTimeout time.Duration) {
-	ctx, cancel := context.WithTimeout(context.Background(), subTaskTimeout)
-	defer cancel()
+func resultHandler(
+	log *slog.Logger,
+	aggregator *aggregator,
+	results <-chan partResult,
+	reports chan<- taskReport,
+	ttl time.Duration,
+) {
+	const op = "service.resultHandler"
+
+	log = log.With(
+		slog.String("op", op),
+	)
+
+	defer close(reports)
 
-	generator := NewPermutationGenerator(part.Alphabet, part.Start, part.End-part.Start)
-	result := make([]string, 0)
-	var err error = nil
+	ticker := time.NewTicker(max(ttl/2, time.Second))
+	defer ticker.Stop()
 
-outer:
-	for generator.HasNext() {
+	for {
 		select {
-		case <-ctx.Done():
-			{
-				err = ctx.Err()
-				break outer
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+
+			log.Info("serving partial result", slog.Int("part", result.index), slog.Any("result", result.part))
+
+			for _, report := range aggregator.add(result) {
+				reports <- report
 			}
-		default:
-			{
-				value := generator.Next()
-				log.Debug("generated value", slog.Any("value", value))
-				hashBytes := md5.Sum([]byte(value))
-				hash := hex.EncodeToString(hashBytes[:])
-				if hash == part.Hash {
-					result = append(result, value)
-				}
+		case <-ticker.C:
+			for _, report := range aggregator.expire() {
+				reports <- report
 			}
 		}
 	}
+}
+
+func reporter(
+	log *slog.Logger,
+	outbox *Outbox,
+	checkpoints *CheckpointStore,
+	reports <-chan taskReport,
+	metrics *metrics.Metrics,
+	done chan<- struct{},
+) {
+	const op = "service.reporter"
+
+	log = log.With(
+		slog.String("op", op),
+	)
+
+	defer close(done)
+
+	for report := range reports {
+		deliverReport(log, outbox, checkpoints, metrics, report)
+	}
+}
+
+func deliverReport(
+	log *slog.Logger,
+	outbox *Outbox,
+	checkpoints *CheckpointStore,
+	metrics *metrics.Metrics,
+	report taskReport,
+) {
+	result := report.result
+
+	log.Info("reporting task result", slog.String("task_id", result.TaskId), slog.String("status", result.Status))
+	metrics.TaskFinished(result.Status)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), report.span)
+	if err := outbox.Enqueue(ctx, mapResultToRequest(result), taskFingerprint(report.run.task)); err != nil {
+		log.Error("failed to enqueue completion report", slogattr.Err(err))
+		return
+	}
+
+	checkpoints.remove(report.run)
+}
+
+func mapResultToRequest(result model.TaskResult) client.CompleteRequest {
+	return client.CompleteRequest{
+		RequestId: result.RequestId,
+		TaskId:    result.TaskId,
+		Status:    result.Status,
+		Start:     result.Start,
+		End:       result.End,
+		Matches:   result.Matches,
+		Covered:   mapRangesToRequest(result.Covered),
+		Uncovered: mapRangesToRequest(result.Uncovered),
+	}
+}
+
+func mapRangesToRequest(ranges []model.Range) []client.Range {
+	mapped := make([]client.Range, len(ranges))
+	for i, r := range ranges {
+		mapped[i] = client.Range{
+			Start: r.Start,
+			End:   r.End,
+		}
+	}
+
+	return mapped
+}
+
+func handlePart(
+	log *slog.Logger,
+	wordlists *WordlistStore,
+	job partJob,
+	results chan<- partResult,
+	chunkTimeout time.Duration,
+	metrics *metrics.Metrics,
+) {
+	part := job.part
+
+	ctx, span := tracer.Start(job.run.ctx, "part", trace.WithAttributes(
+		attribute.Int("part.index", job.index),
+		attribute.String("part.start", strconv.FormatUint(part.Start, 10)),
+		attribute.String("part.end", strconv.FormatUint(part.End, 10)),
+	))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, chunkTimeout)
+	defer cancel()
+
+	job.progress.run()
+
+	startedAt := time.Now()
+	matches, reached, err := crackPart(ctx, wordlists, part, job.progress)
+	job.run.throughput.observe(reached-part.Start, time.Since(startedAt))
+	state := partState(part, matches, err)
+	span.SetAttributes(
+		attribute.String("part.state", state),
+		attribute.String("part.reached", strconv.FormatUint(reached, 10)),
+	)
+	if state == model.PartStateFailed || state == model.PartStateTimedOut {
+		span.SetStatus(codes.Error, state)
+	}
+	job.progress.finish(state, reached-part.Start)
+	metrics.PartFinished(state)
+	metrics.CandidatesHashed(part.Algorithm, reached-part.Start)
+
+	if err == nil && part.StopOnFirstMatch && len(matches) > 0 {
+		log.Info("match found, stopping other parts of task", slog.String("task_id", part.TaskId))
+		job.run.cancel(model.ErrMatchFound)
+	}
 
 	completedPart := model.CompletedPart{
 		RequestId: part.RequestId,
 		TaskId:    part.TaskId,
-		Data:      result,
+		Matches:   matches,
 		Start:     part.Start,
 		End:       part.End,
+		Reached:   reached,
 		Error:     err,
 	}
 
 	log.Info("completed part", slog.Any("completed part", completedPart))
-	results <- completedPart
+	results <- partResult{run: job.run, index: job.index, part: completedPart}
+}
+
+const cancellationCheckInterval = 1024
+
+func crackPart(ctx context.Context, wordlists *WordlistStore, part model.Part, progress *partProgress) (map[string][]string, uint64, error) {
+	matches := make(map[string][]string)
+
+	h, ok := hasher.Lookup(part.Algorithm)
+	if !ok {
+		return matches, part.Start, fmt.Errorf("unknown hash algorithm %q", part.Algorithm)
+	}
+
+	targets, err := newTargetSet(h, part.Hashes)
+	if err != nil {
+		return matches, part.Start, err
+	}
+
+	generator, err := newGenerator(wordlists, part)
+	if err != nil {
+		return matches, part.Start, err
+	}
+
+	if closer, ok := generator.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	word := make([]byte, 0, 64)
+	digest := make([]byte, 0, h.Size())
+
+	i := uint64(0)
+	for ; generator.HasNext(); i++ {
+		if i%cancellationCheckInterval == 0 {
+			progress.update(i)
+
+			if ctx.Err() != nil {
+				return matches, part.Start + i, context.Cause(ctx)
+			}
+		}
+
+		word = generator.AppendNext(word[:0])
+		digest = h.Sum(digest[:0], word)
+
+		if hash, ok := targets.lookup(digest); ok {
+			match := string(word)
+			matches[hash] = append(matches[hash], match)
+			progress.match(hash, match)
+
+			if part.StopOnFirstMatch {
+				return matches, part.Start + i + 1, nil
+			}
+		}
+	}
+
+	if failing, ok := generator.(failingGenerator); ok && failing.Err() != nil {
+		return matches, part.Start + i, failing.Err()
+	}
+
+	return matches, part.Start + i, nil
 }
 
-func (s *CrackService) StartTask(task model.Task) {
+func (s *CrackService) StartTask(ctx context.Context, task model.Task) (model.TaskProgress, bool, error) {
+	ctx, span := tracer.Start(ctx, "task", trace.WithAttributes(taskAttributes(task)...))
+
+	progress, created, err := s.startTask(ctx, task)
+	if err != nil {
+		endSpanWithError(span, err)
+		return model.TaskProgress{}, false, err
+	}
+
+	if !created {
+		span.SetAttributes(attribute.Bool("task.duplicate", true))
+		span.End()
+	}
+
+	return progress, created, nil
+}
+
+func (s *CrackService) startTask(ctx context.Context, task model.Task) (model.TaskProgress, bool, error) {
+	const op = "service.CrackService.StartTask"
+
 	s.log.Info("starting task", slog.Any("task", task))
 
-	separated := s.separate(task)
+	if progress, ok, err := s.existingTask(task); ok || err != nil {
+		if err != nil {
+			return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return progress, false, nil
+	}
+
+	if task.StopOnFirstMatch && len(task.Hashes) > 1 {
+		s.log.Error("stop on first match requested for multiple targets", slog.Any("task", task))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: %d hashes: %w", op, len(task.Hashes), model.ErrMultipleTargets)
+	}
+
+	keyspaceSize, fits, err := keyspaceSize(s.wordlists, task)
+	if err != nil {
+		s.log.Error("unable to compute keyspace", slog.Any("task", task), slogattr.Err(err))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !fits {
+		s.log.Info("keyspace exceeds 64-bit index range", slog.Any("task", task))
+	} else if task.End > keyspaceSize {
+		s.log.Error("task range is out of keyspace", slog.Any("task", task), slog.Uint64("keyspace size", keyspaceSize))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: end %d exceeds keyspace size %d: %w", op, task.End, keyspaceSize, model.ErrRangeOutOfKeyspace)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		s.log.Warn("task rejected: service is shutting down", slog.String("task_id", task.TaskId))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, model.ErrShuttingDown)
+	}
+
+	if !s.registration.Registered() {
+		s.log.Warn("task rejected: worker is not registered yet", slog.String("task_id", task.TaskId))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, model.ErrNotReady)
+	}
+
+	run, ok := s.registry.add(ctx, task)
+	if !ok {
+		progress, _, err := s.existingTask(task)
+		if err != nil {
+			return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return progress, false, nil
+	}
+
+	select {
+	case s.queue <- run:
+		s.log.Info("task queued", slog.String("task_id", task.TaskId), slog.Int("queue depth", s.queueDepth()))
+		s.metrics.TaskReceived()
+
+		if err := s.checkpoints.save(run); err != nil {
+			s.log.Error("error saving checkpoint", slog.String("task_id", task.TaskId), slogattr.Err(err))
+		}
+
+		return model.TaskProgress{}, true, nil
+	default:
+		s.registry.removeRun(run)
+		s.log.Warn("task rejected: queue is full", slog.String("task_id", task.TaskId), slog.Int("queue capacity", cap(s.queue)))
+		return model.TaskProgress{}, false, fmt.Errorf("%s: %w", op, model.ErrQueueFull)
+	}
+}
+
+func (s *CrackService) knownTask(taskId string) (string, model.TaskProgress, bool) {
+	if fingerprint, progress, ok := s.registry.existing(taskId); ok {
+		return fingerprint, progress, true
+	}
+
+	record, ok := s.outbox.report(taskId)
+	if !ok {
+		return "", model.TaskProgress{}, false
+	}
+
+	return record.Fingerprint, mapReportToProgress(record.Request), true
+}
+
+func mapReportToProgress(request client.CompleteRequest) model.TaskProgress {
+	progress := model.TaskProgress{
+		RequestId: request.RequestId,
+		TaskId:    request.TaskId,
+		State:     request.Status,
+		Start:     request.Start,
+		End:       request.End,
+	}
 
-	s.log.Info("separated task", slog.Any("parts", separated))
+	if progress.State == "" {
+		progress.State = model.TaskStatusCompleted
+	}
+
+	for _, r := range request.Covered {
+		progress.Tested += r.End - r.Start
+	}
+	progress.Dispatched = progress.Tested
+
+	return progress
+}
+
+func (s *CrackService) existingTask(task model.Task) (model.TaskProgress, bool, error) {
+	fingerprint, progress, ok := s.knownTask(task.TaskId)
+	if !ok {
+		return model.TaskProgress{}, false, nil
+	}
 
-	for _, part := range separated {
-		s.parts <- part
+	if fingerprint != "" && fingerprint != taskFingerprint(task) {
+		s.log.Warn("task rejected: task already exists with a different payload", slog.String("task_id", task.TaskId))
+		return model.TaskProgress{}, true, fmt.Errorf("task %q: %w", task.TaskId, model.ErrTaskConflict)
 	}
+
+	s.log.Info("duplicate task submission, answering with existing task status", slog.String("task_id", task.TaskId), slog.String("state", progress.State))
+
+	return progress, true, nil
 }
 
-func (s *CrackService) separate(task model.Task) []model.Part {
-	separated := make([]model.Part, s.workersCount)
-	totalSize := task.End - task.Start
-	partSize := totalSize / s.workersCount
+func (s *CrackService) CancelTask(taskId string) error {
+	const op = "service.CrackService.CancelTask"
 
-	start := task.Start
+	run, ok := s.registry.get(taskId)
+	if !ok {
+		s.log.Warn("unable to cancel task: task not found", slog.String("task_id", taskId))
+		return fmt.Errorf("%s: task %q: %w", op, taskId, model.ErrTaskNotFound)
+	}
+
+	if !run.claim() {
+		run.cancel(model.ErrTaskCancelled)
+		s.log.Info("task cancelled", slog.String("task_id", taskId))
+		return nil
+	}
 
-	for i := uint64(0); i < s.workersCount; i++ {
-		part := model.Part{
-			RequestId: task.RequestId,
-			TaskId:    task.TaskId,
-			Alphabet:  task.Alphabet,
-			Hash:      task.Hash,
-			MaxLength: task.MaxLength,
-			Start:     start,
-			End:       start + partSize,
+	s.skipped.Add(1)
+	run.cancel(model.ErrTaskCancelled)
+
+	s.log.Info("queued task cancelled", slog.String("task_id", taskId))
+
+	for _, report := range s.aggregator.conclude(run, model.TaskStatusCancelled) {
+		deliverReport(s.log, s.outbox, s.checkpoints, s.metrics, report)
+	}
+
+	return nil
+}
+
+func (s *CrackService) queueDepth() int {
+	return max(len(s.queue)-int(s.skipped.Load()), 0)
+}
+
+const stuckGoroutineGrace = 30 * time.Second
+
+func (s *CrackService) Health() model.WorkerHealth {
+	threshold := s.chunkTimeout + stuckGoroutineGrace
+	now := time.Now()
+
+	stuck := uint64(0)
+	for i := range s.busySince {
+		since := s.busySince[i].Load()
+		if since != 0 && now.Sub(time.Unix(0, since)) > threshold {
+			stuck++
 		}
+	}
 
-		separated[i] = part
+	return model.WorkerHealth{
+		Alive:           stuck == 0,
+		StuckGoroutines: stuck,
+	}
+}
+
+func (s *CrackService) Readiness() model.WorkerReadiness {
+	s.mu.RLock()
+	running := !s.closed && s.workersCount > 0
+	s.mu.RUnlock()
+
+	registered := s.registration.Registered()
+	saturated := cap(s.queue) > 0 && s.queueDepth() >= cap(s.queue)
+
+	return model.WorkerReadiness{
+		Ready:          registered && running && !saturated,
+		Registered:     registered,
+		PoolRunning:    running,
+		QueueSaturated: saturated,
+	}
+}
+
+func (s *CrackService) TaskProgress(taskId string) (model.TaskProgress, error) {
+	const op = "service.CrackService.TaskProgress"
 
-		start += partSize
+	if run, ok := s.registry.get(taskId); ok {
+		return run.progress.snapshot(run.task, true), nil
 	}
 
-	separated[s.workersCount-1].End = task.End
+	if _, progress, ok := s.knownTask(taskId); ok {
+		return progress, nil
+	}
 
-	return separated
+	return model.TaskProgress{}, fmt.Errorf("%s: task %q: %w", op, taskId, model.ErrTaskNotFound)
 }
 
-func (s *CrackService) Close() error {
-	const op = "service.CrackService.Close"
+func (s *CrackService) Tasks() []model.TaskProgress {
+	return s.registry.tasks()
+}
+
+func (s *CrackService) HashRates() map[string]float64 {
+	rates := make(map[string]float64)
+	for _, run := range s.registry.list() {
+		rates[run.task.Algorithm] += run.progress.snapshot(run.task, false).HashesPerSecond
+	}
+
+	return rates
+}
+
+func (s *CrackService) ActiveTaskIds() []string {
+	return s.registry.taskIds()
+}
+
+func (s *CrackService) Status() model.WorkerStatus {
+	return model.WorkerStatus{
+		QueueDepth:    s.queueDepth(),
+		QueueCapacity: cap(s.queue),
+		Workers:       s.workersCount,
+		BusyWorkers:   s.busyWorkers.Load(),
+		Registered:    s.registration.Registered(),
+	}
+}
+
+const drainReportShare = 5
+
+func (s *CrackService) Drain(ctx context.Context) error {
+	s.drainOnce.Do(func() {
+		s.drainErr = s.drain(ctx)
+	})
+
+	return s.drainErr
+}
+
+func (s *CrackService) drain(ctx context.Context) error {
+	const op = "service.CrackService.drain"
 
 	log := s.log.With(
 		slog.String("operation", op),
@@ -221,11 +710,49 @@ func (s *CrackService) Close() error {
 
 	log.Info("stopping...")
 
-	close(s.parts)
-	s.wg.Wait()
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Until(deadline)/drainReportShare))
+		defer cancel()
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	s.draining.Store(true)
+	close(s.queue)
+	s.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		<-s.dispatcherDone
+		close(s.parts)
+		s.wg.Wait()
+		close(stopped)
+	}()
+
+	var err error
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn("in-flight parts did not finish in time, checkpointing them", slog.Any("tasks", s.registry.taskIds()))
+		err = fmt.Errorf("%s: in-flight parts did not finish in time: %w", op, ctx.Err())
+		s.registry.cancelAll(model.ErrShuttingDown)
+		<-stopped
+	}
+
 	close(s.results)
 
+	<-s.reporterDone
+
+	close(s.checkpointStop)
+	<-s.checkpointDone
+
 	log.Info("stopped")
 
-	return nil
+	return err
+}
+
+func (s *CrackService) Close() error {
+	return s.Drain(context.Background())
 }
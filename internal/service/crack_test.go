@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/fatalistix/crack-hash-worker/internal/config"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/crack-hash-worker/internal/hasher"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/crack-hash-worker/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const benchmarkAlphabet = "abcdefghijklmnopqrstuvwxyz1234567890"
+
+type fakeManager struct {
+	address string
+	mu      sync.Mutex
+	events  []string
+	reports map[string]client.CompleteRequest
+}
+
+func newFakeManager(t *testing.T) *fakeManager {
+	m := &fakeManager{reports: make(map[string]client.CompleteRequest)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/deregister"):
+			m.events = append(m.events, "deregister")
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasSuffix(r.URL.Path, "/register"):
+			m.events = append(m.events, "register")
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(client.RegisterResponse{WorkerId: "w1"})
+		case r.Method == http.MethodPatch:
+			var request client.CompleteRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			m.events = append(m.events, "complete "+request.TaskId)
+			m.reports[request.TaskId] = request
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	m.address = strings.TrimPrefix(server.URL, "http://")
+
+	return m
+}
+
+func (m *fakeManager) report(taskId string) (client.CompleteRequest, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	request, ok := m.reports[taskId]
+	return request, ok
+}
+
+func (m *fakeManager) history() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.events)
+}
+
+func newRegisteredCrackService(t *testing.T, manager *fakeManager, workerConfig config.WorkerConfig) (*CrackService, *Outbox, *Registration) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	registration := NewRegistration(log, manager.address, 8080, time.Millisecond, 10*time.Millisecond)
+	assert.NoError(t, registration.Register(context.Background()))
+
+	service, outbox := newTestCrackService(t, manager.address, registration, workerConfig)
+
+	return service, outbox, registration
+}
+
+func longTask(taskId string) model.Task {
+	keyspaceSize, _ := KeyspaceSize(uint64(len(benchmarkAlphabet)), 8)
+
+	return model.Task{
+		TaskId:           taskId,
+		Mode:             model.ModeBruteForce,
+		Alphabet:         benchmarkAlphabet,
+		AlphabetEncoding: model.AlphabetEncodingUTF8,
+		Algorithm:        hasher.MD5,
+		Hashes:           []string{"00000000000000000000000000000000"},
+		MaxLength:        8,
+		Start:            0,
+		End:              keyspaceSize,
+	}
+}
+
+func waitForState(t *testing.T, service *CrackService, taskId, state string) {
+	assert.Eventually(t, func() bool {
+		progress, err := service.TaskProgress(taskId)
+		return err == nil && progress.State == state
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func waitForReport(t *testing.T, manager *fakeManager, taskId string) client.CompleteRequest {
+	var request client.CompleteRequest
+	assert.Eventually(t, func() bool {
+		var ok bool
+		request, ok = manager.report(taskId)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return request
+}
+
+func newTestCrackService(t *testing.T, managerAddress string, registration *Registration, workerConfig config.WorkerConfig) (*CrackService, *Outbox) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := metrics.New()
+
+	outbox, err := NewOutbox(log, t.TempDir(), managerAddress, registration, time.Millisecond, 10*time.Millisecond, m)
+	assert.NoError(t, err)
+
+	checkpoints, err := NewCheckpointStore(log, t.TempDir())
+	assert.NoError(t, err)
+
+	workerConfig.ChunkTimeout = time.Minute
+	workerConfig.WordlistDir = t.TempDir()
+	workerConfig.WordlistIndexDir = t.TempDir()
+
+	service := NewCrackService(log, workerConfig, registration, outbox, checkpoints, m)
+	t.Cleanup(func() {
+		_ = service.Close()
+		_ = outbox.Close()
+	})
+
+	return service, outbox
+}
+
+func TestCrackPart(t *testing.T) {
+	keyspaceSize, _ := KeyspaceSize(uint64(len(benchmarkAlphabet)), 4)
+
+	part := model.Part{
+		Mode:             model.ModeBruteForce,
+		Alphabet:         benchmarkAlphabet,
+		AlphabetEncoding: model.AlphabetEncodingUTF8,
+		Algorithm:        hasher.MD5,
+		Hashes:           []string{"e2fc714c4727ee9395f324cd2e7f331f", "900150983cd24fb0d6963f7d28e17f72"},
+		MaxLength:        4,
+		Start:            0,
+		End:              keyspaceSize,
+	}
+
+	matches, _, err := crackPart(context.Background(), nil, part, new(partProgress))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"e2fc714c4727ee9395f324cd2e7f331f": {"abcd"},
+		"900150983cd24fb0d6963f7d28e17f72": {"abc"},
+	}, matches)
+}
+
+func TestHandlePart_StopOnFirstMatch(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	keyspaceSize, _ := KeyspaceSize(uint64(len(benchmarkAlphabet)), 4)
+
+	agg, registry := newTestAggregator(time.Minute)
+	run, _ := registry.add(context.Background(), model.Task{
+		TaskId:           "task",
+		Mode:             model.ModeBruteForce,
+		Alphabet:         benchmarkAlphabet,
+		AlphabetEncoding: model.AlphabetEncodingUTF8,
+		Algorithm:        hasher.MD5,
+		Hashes:           []string{"e2fc714c4727ee9395f324cd2e7f331f"},
+		MaxLength:        4,
+		Start:            0,
+		End:              keyspaceSize,
+		StopOnFirstMatch: true,
+	})
+
+	const partsCount = 64
+	size := keyspaceSize / partsCount
+
+	agg.begin(run)
+	results := make(chan partResult, partsCount)
+	jobs := make([]partJob, 0, partsCount)
+	for i := 0; i < partsCount; i++ {
+		end := uint64(i+1) * size
+		if i == partsCount-1 {
+			end = keyspaceSize
+		}
+
+		part := makePart(run.task, uint64(i)*size, end)
+		assert.True(t, agg.dispatch(run, i, part))
+		jobs = append(jobs, partJob{run: run, index: i, part: part, progress: run.progress.dispatch(i, part)})
+	}
+	assert.Empty(t, agg.seal(run, true))
+
+	m := metrics.New()
+	reports := make([]taskReport, 0)
+	for _, job := range jobs {
+		handlePart(log, nil, job, results, time.Minute, m)
+		reports = append(reports, agg.add(<-results)...)
+	}
+
+	assert.ErrorIs(t, context.Cause(run.ctx), model.ErrMatchFound)
+	assert.Equal(t, model.PartStateCancelled, jobs[partsCount-1].progress.snapshot(time.Now()).State)
+
+	assert.Len(t, reports, 1)
+	result := reports[0].result
+	assert.Equal(t, model.TaskStatusFound, result.Status)
+	assert.Equal(t, map[string][]string{"e2fc714c4727ee9395f324cd2e7f331f": {"abcd"}}, result.Matches)
+	assert.Len(t, result.Covered, 1)
+	assert.Equal(t, uint64(0), result.Covered[0].Start)
+	assert.Equal(t, []model.Range{{Start: result.Covered[0].End, End: keyspaceSize}}, result.Uncovered)
+}
+
+func TestCrackService_QueueFull(t *testing.T) {
+	manager := newFakeManager(t)
+	service, _, _ := newRegisteredCrackService(t, manager, config.WorkerConfig{GoroutineCount: 1, QueueSize: 1})
+
+	running, queued, rejected := longTask("running"), longTask("queued"), longTask("rejected")
+
+	_, _, err := service.StartTask(context.Background(), running)
+	assert.NoError(t, err)
+	waitForState(t, service, running.TaskId, model.TaskStateRunning)
+
+	_, _, err = service.StartTask(context.Background(), queued)
+	assert.NoError(t, err)
+	assert.True(t, service.Readiness().QueueSaturated)
+
+	_, created, err := service.StartTask(context.Background(), rejected)
+	assert.ErrorIs(t, err, model.ErrQueueFull)
+	assert.False(t, created)
+
+	_, ok := service.registry.get(rejected.TaskId)
+	assert.False(t, ok)
+	_, err = service.TaskProgress(rejected.TaskId)
+	assert.ErrorIs(t, err, model.ErrTaskNotFound)
+	assert.Equal(t, []string{queued.TaskId, running.TaskId}, service.ActiveTaskIds())
+
+	assert.NoError(t, service.CancelTask(queued.TaskId))
+	assert.NoError(t, service.CancelTask(running.TaskId))
+	waitForReport(t, manager, running.TaskId)
+}
+
+func TestCrackService_Drain(t *testing.T) {
+	manager := newFakeManager(t)
+	service, outbox, registration := newRegisteredCrackService(t, manager, config.WorkerConfig{GoroutineCount: 1, QueueSize: 1})
+
+	running, queued := longTask("running"), longTask("queued")
+
+	_, _, err := service.StartTask(context.Background(), running)
+	assert.NoError(t, err)
+	waitForState(t, service, running.TaskId, model.TaskStateRunning)
+
+	_, _, err = service.StartTask(context.Background(), queued)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	assert.NoError(t, service.Drain(ctx))
+
+	_, _, err = service.StartTask(context.Background(), longTask("late"))
+	assert.ErrorIs(t, err, model.ErrShuttingDown)
+
+	assert.NoError(t, outbox.Drain(ctx))
+	assert.NoError(t, registration.Drain(ctx))
+
+	report, ok := manager.report(queued.TaskId)
+	assert.True(t, ok)
+	assert.Equal(t, model.TaskStatusPartial, report.Status)
+	assert.Empty(t, report.Covered)
+	assert.Equal(t, []client.Range{{Start: queued.Start, End: queued.End}}, report.Uncovered)
+
+	report, ok = manager.report(running.TaskId)
+	assert.True(t, ok)
+	assert.Equal(t, model.TaskStatusPartial, report.Status)
+	if assert.Len(t, report.Covered, 1) {
+		covered := report.Covered[0]
+		assert.Equal(t, running.Start, covered.Start)
+		assert.Greater(t, covered.End, covered.Start)
+		assert.Equal(t, []client.Range{{Start: covered.End, End: running.End}}, report.Uncovered)
+	}
+
+	history := manager.history()
+	assert.Equal(t, "deregister", history[len(history)-1])
+	assert.Contains(t, history, "complete "+running.TaskId)
+	assert.Contains(t, history, "complete "+queued.TaskId)
+}
+
+func TestCrackService_CancelQueued(t *testing.T) {
+	manager := newFakeManager(t)
+	service, _, _ := newRegisteredCrackService(t, manager, config.WorkerConfig{GoroutineCount: 1, QueueSize: 2})
+
+	running, queued := longTask("running"), longTask("queued")
+
+	_, created, err := service.StartTask(context.Background(), running)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	waitForState(t, service, running.TaskId, model.TaskStateRunning)
+
+	_, created, err = service.StartTask(context.Background(), queued)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	waitForState(t, service, queued.TaskId, model.TaskStateQueued)
+	assert.Equal(t, 1, service.Status().QueueDepth)
+
+	assert.NoError(t, service.CancelTask(queued.TaskId))
+
+	progress, err := service.TaskProgress(queued.TaskId)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TaskStatusCancelled, progress.State)
+	assert.Equal(t, 0, service.Status().QueueDepth)
+	assert.Equal(t, []string{running.TaskId}, service.ActiveTaskIds())
+
+	report := waitForReport(t, manager, queued.TaskId)
+	assert.Equal(t, model.TaskStatusCancelled, report.Status)
+	assert.Empty(t, report.Covered)
+	assert.Equal(t, []client.Range{{Start: queued.Start, End: queued.End}}, report.Uncovered)
+
+	assert.ErrorIs(t, service.CancelTask(queued.TaskId), model.ErrTaskNotFound)
+	assert.NoError(t, service.CancelTask(running.TaskId))
+	waitForReport(t, manager, running.TaskId)
+}
+
+func TestCrackService_CancelRunning(t *testing.T) {
+	manager := newFakeManager(t)
+	service, _, _ := newRegisteredCrackService(t, manager, config.WorkerConfig{GoroutineCount: 2, QueueSize: 1})
+
+	task := longTask("running")
+
+	_, created, err := service.StartTask(context.Background(), task)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	waitForState(t, service, task.TaskId, model.TaskStateRunning)
+
+	assert.NoError(t, service.CancelTask(task.TaskId))
+	waitForState(t, service, task.TaskId, model.TaskStatusCancelled)
+
+	report := waitForReport(t, manager, task.TaskId)
+	assert.Equal(t, model.TaskStatusCancelled, report.Status)
+	assert.NotEmpty(t, report.Uncovered)
+	assert.Empty(t, service.ActiveTaskIds())
+
+	covered := uint64(0)
+	for _, r := range append(report.Covered, report.Uncovered...) {
+		covered += r.End - r.Start
+	}
+	assert.Equal(t, task.End-task.Start, covered)
+}
+
+func BenchmarkCrackPart_Legacy(b *testing.B) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	target := "e2fc714c4727ee9395f324cd2e7f331f"
+	alphabet, err := decodeAlphabet(benchmarkAlphabet, model.AlphabetEncodingUTF8)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	generator := NewPermutationGenerator(alphabet, 0, uint64(b.N), 8)
+	result := make([]string, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for generator.HasNext() {
+		value := generator.Next()
+		log.Debug("generated value", slog.Any("value", value))
+		hashBytes := md5.Sum([]byte(value))
+		hash := hex.EncodeToString(hashBytes[:])
+		if hash == target {
+			result = append(result, value)
+		}
+	}
+
+	reportHashRate(b)
+}
+
+func BenchmarkCrackPart(b *testing.B) {
+	part := model.Part{
+		Mode:             model.ModeBruteForce,
+		Alphabet:         benchmarkAlphabet,
+		AlphabetEncoding: model.AlphabetEncodingUTF8,
+		Algorithm:        hasher.MD5,
+		Hashes:           []string{"e2fc714c4727ee9395f324cd2e7f331f"},
+		MaxLength:        8,
+		Start:            0,
+		End:              uint64(b.N),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	if _, _, err := crackPart(context.Background(), nil, part, new(partProgress)); err != nil {
+		b.Fatal(err)
+	}
+
+	reportHashRate(b)
+}
+
+func reportHashRate(b *testing.B) {
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "hashes/s")
+}
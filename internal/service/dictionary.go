@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+type DictionaryGenerator struct {
+	file   *os.File
+	reader *bufio.Reader
+	id     uint64
+	size   uint64
+	err    error
+}
+
+func NewDictionaryGenerator(wordlist *Wordlist, n, size uint64) (*DictionaryGenerator, error) {
+	g := &DictionaryGenerator{
+		id:   0,
+		size: size,
+	}
+
+	if size == 0 {
+		return g, nil
+	}
+
+	offset, err := wordlist.offset(n)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(wordlist.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	g.file = file
+	g.reader = bufio.NewReaderSize(file, 1<<16)
+
+	return g, nil
+}
+
+func (g *DictionaryGenerator) AppendNext(dst []byte) []byte {
+	g.id++
+
+	for {
+		line, err := g.reader.ReadSlice('\n')
+		dst = append(dst, line...)
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			g.err = err
+		} else if errors.Is(err, io.EOF) && g.id < g.size {
+			g.err = io.ErrUnexpectedEOF
+		}
+
+		break
+	}
+
+	dst = bytes.TrimSuffix(dst, []byte{'\n'})
+	dst = bytes.TrimSuffix(dst, []byte{'\r'})
+
+	return dst
+}
+
+func (g *DictionaryGenerator) HasNext() bool {
+	return g.err == nil && g.id < g.size
+}
+
+func (g *DictionaryGenerator) Err() error {
+	return g.err
+}
+
+func (g *DictionaryGenerator) Close() error {
+	if g.file == nil {
+		return nil
+	}
+
+	return g.file.Close()
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionaryGenerator_Next(t *testing.T) {
+	dir := t.TempDir()
+	content := "password\r\n123456\n\nqwerty\nletmein"
+	if err := os.WriteFile(filepath.Join(dir, "words.txt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewWordlistStore(slog.New(slog.NewTextHandler(io.Discard, nil)), dir, t.TempDir())
+
+	wordlist, err := store.Open("words.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), wordlist.Lines())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "index must not be written into the wordlist directory")
+
+	g, err := NewDictionaryGenerator(wordlist, 1, 4)
+	assert.NoError(t, err)
+	defer g.Close()
+
+	words := make([]string, 0)
+	for g.HasNext() {
+		words = append(words, string(g.AppendNext(nil)))
+	}
+
+	assert.NoError(t, g.Err())
+	assert.Equal(t, []string{"123456", "", "qwerty", "letmein"}, words)
+}
+
+func TestWordlistStore_Open_Unknown(t *testing.T) {
+	store := NewWordlistStore(slog.New(slog.NewTextHandler(io.Discard, nil)), t.TempDir(), t.TempDir())
+
+	for _, name := range []string{"missing.txt", "../words.txt", ""} {
+		_, err := store.Open(name)
+		assert.ErrorIs(t, err, model.ErrUnknownWordlist)
+	}
+}
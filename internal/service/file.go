@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const tempFileSuffix = ".tmp"
+
+func writeFileAtomic(path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + tempFileSuffix
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
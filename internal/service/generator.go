@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+)
+
+type candidateGenerator interface {
+	HasNext() bool
+	AppendNext(dst []byte) []byte
+}
+
+type failingGenerator interface {
+	Err() error
+}
+
+func newGenerator(wordlists *WordlistStore, part model.Part) (candidateGenerator, error) {
+	switch part.Mode {
+	case model.ModeBruteForce:
+		alphabet, err := decodeAlphabet(part.Alphabet, part.AlphabetEncoding)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPermutationGenerator(alphabet, part.Start, part.End-part.Start, part.MaxLength), nil
+	case model.ModeMask:
+		charsets, err := parseMask(part.Mask, part.CustomCharsets)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewMaskGenerator(charsets, part.Start, part.End-part.Start), nil
+	case model.ModeDictionary:
+		wordlist, err := wordlists.Open(part.Wordlist)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(part.Rules) == 0 {
+			return NewDictionaryGenerator(wordlist, part.Start, part.End-part.Start)
+		}
+
+		rules, err := ParseRules(part.Rules)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewRuleGenerator(wordlist, rules, part.Start, part.End-part.Start)
+	default:
+		return nil, fmt.Errorf("%q: %w", part.Mode, model.ErrUnknownMode)
+	}
+}
+
+func keyspaceSize(wordlists *WordlistStore, task model.Task) (uint64, bool, error) {
+	switch task.Mode {
+	case model.ModeBruteForce:
+		alphabet, err := decodeAlphabet(task.Alphabet, task.AlphabetEncoding)
+		if err != nil {
+			return 0, false, err
+		}
+
+		size, fits := KeyspaceSize(uint64(len(alphabet)), task.MaxLength)
+		return size, fits, nil
+	case model.ModeMask:
+		charsets, err := parseMask(task.Mask, task.CustomCharsets)
+		if err != nil {
+			return 0, false, err
+		}
+
+		size, fits := MaskKeyspaceSize(charsets)
+		return size, fits, nil
+	case model.ModeDictionary:
+		wordlist, err := wordlists.Open(task.Wordlist)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if len(task.Rules) == 0 {
+			return wordlist.Lines(), true, nil
+		}
+
+		rules, err := ParseRules(task.Rules)
+		if err != nil {
+			return 0, false, err
+		}
+
+		size, fits := RuleKeyspaceSize(wordlist.Lines(), len(rules))
+		return size, fits, nil
+	default:
+		return 0, false, fmt.Errorf("%q: %w", task.Mode, model.ErrUnknownMode)
+	}
+}
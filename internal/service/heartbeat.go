@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/slogattr"
+	"log/slog"
+	"time"
+)
+
+type Heartbeat struct {
+	log            *slog.Logger
+	heartbeater    *client.Heartbeater
+	managerAddress string
+	interval       time.Duration
+	registration   *Registration
+	crackService   *CrackService
+	ctx            context.Context
+	cancel         context.CancelFunc
+	done           chan struct{}
+}
+
+func NewHeartbeat(
+	log *slog.Logger,
+	managerAddress string,
+	interval time.Duration,
+	registration *Registration,
+	crackService *CrackService,
+) *Heartbeat {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := &Heartbeat{
+		log:            log,
+		heartbeater:    client.NewHeartbeater(log),
+		managerAddress: managerAddress,
+		interval:       interval,
+		registration:   registration,
+		crackService:   crackService,
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan struct{}),
+	}
+
+	if interval <= 0 {
+		log.Warn("heartbeats disabled")
+		close(h.done)
+		return h
+	}
+
+	go h.run()
+
+	return h
+}
+
+func (h *Heartbeat) Drain(context.Context) error {
+	return h.Close()
+}
+
+func (h *Heartbeat) Close() error {
+	h.cancel()
+	<-h.done
+
+	return nil
+}
+
+func (h *Heartbeat) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			h.beat()
+		}
+	}
+}
+
+func (h *Heartbeat) beat() {
+	const op = "service.Heartbeat.beat"
+
+	log := h.log.With(
+		slog.String("op", op),
+	)
+
+	workerId, ok := h.registration.WorkerId()
+	if !ok {
+		return
+	}
+
+	status := h.crackService.Status()
+	request := client.HeartbeatRequest{
+		WorkerId:      workerId,
+		Workers:       status.Workers,
+		BusyWorkers:   status.BusyWorkers,
+		QueueDepth:    status.QueueDepth,
+		QueueCapacity: status.QueueCapacity,
+		ActiveTaskIds: h.crackService.ActiveTaskIds(),
+	}
+
+	err := h.heartbeater.Heartbeat(h.ctx, h.managerAddress, request)
+	if err == nil {
+		log.Debug("heartbeat sent", slog.Any("request", request))
+		return
+	}
+
+	if !errors.Is(err, client.ErrUnknownWorker) {
+		log.Warn("failed to send heartbeat", slogattr.Err(err))
+		return
+	}
+
+	if err := h.registration.Reregister(h.ctx); err != nil {
+		log.Error("failed to re-register worker", slogattr.Err(err))
+	}
+}
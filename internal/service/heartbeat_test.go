@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fatalistix/crack-hash-worker/internal/config"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat_Reregister(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var registers, heartbeats atomic.Int64
+	reregistering := make(chan struct{})
+	release := make(chan struct{})
+	manager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/register"):
+			n := registers.Add(1)
+			if n == 2 {
+				close(reregistering)
+				<-release
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(client.RegisterResponse{WorkerId: "w" + strconv.FormatInt(n, 10)})
+		case strings.HasSuffix(r.URL.Path, "/heartbeat"):
+			if heartbeats.Add(1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer manager.Close()
+
+	address := strings.TrimPrefix(manager.URL, "http://")
+
+	registration := NewRegistration(log, address, 8080, time.Millisecond, 10*time.Millisecond)
+	assert.NoError(t, registration.Register(context.Background()))
+
+	workerId, ok := registration.WorkerId()
+	assert.True(t, ok)
+	assert.Equal(t, "w1", workerId)
+
+	service, _ := newTestCrackService(t, address, registration, config.WorkerConfig{GoroutineCount: 1, QueueSize: 1})
+
+	heartbeat := NewHeartbeat(log, address, 10*time.Millisecond, registration, service)
+	defer heartbeat.Close()
+
+	select {
+	case <-reregistering:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker was not re-registered")
+	}
+
+	assert.False(t, registration.Registered())
+	assert.False(t, service.Readiness().Ready)
+
+	close(release)
+
+	assert.Eventually(t, registration.Registered, 5*time.Second, 10*time.Millisecond)
+	workerId, ok = registration.WorkerId()
+	assert.True(t, ok)
+	assert.Equal(t, "w2", workerId)
+	assert.Eventually(t, func() bool { return heartbeats.Load() > 1 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"unicode/utf8"
+)
+
+const maxCustomCharsets = 4
+
+var builtinCharsets = map[rune]string{
+	'l': "abcdefghijklmnopqrstuvwxyz",
+	'u': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'd': "0123456789",
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+type MaskGenerator struct {
+	charsets [][][]byte
+	current  []uint64
+	id       uint64
+	size     uint64
+}
+
+func NewMaskGenerator(charsets [][][]byte, n, size uint64) *MaskGenerator {
+	current := make([]uint64, len(charsets))
+
+	for i := len(charsets); i > 0; i-- {
+		base := uint64(len(charsets[i-1]))
+		current[i-1] = n % base
+		n /= base
+	}
+
+	return &MaskGenerator{
+		charsets: charsets,
+		current:  current,
+		id:       0,
+		size:     size,
+	}
+}
+
+func MaskKeyspaceSize(charsets [][][]byte) (uint64, bool) {
+	size := uint64(1)
+
+	for _, charset := range charsets {
+		var overflow bool
+		if size, overflow = mulChecked(size, uint64(len(charset))); overflow {
+			return 0, false
+		}
+	}
+
+	return size, true
+}
+
+func (g *MaskGenerator) Next() string {
+	return string(g.AppendNext(nil))
+}
+
+func (g *MaskGenerator) AppendNext(dst []byte) []byte {
+	for i, idx := range g.current {
+		dst = append(dst, g.charsets[i][idx]...)
+	}
+
+	for i := len(g.current) - 1; i >= 0; i-- {
+		g.current[i]++
+		if g.current[i] != uint64(len(g.charsets[i])) {
+			break
+		}
+		g.current[i] = 0
+	}
+
+	g.id++
+	return dst
+}
+
+func (g *MaskGenerator) HasNext() bool {
+	return g.id < g.size
+}
+
+func parseMask(mask string, customCharsets []string) ([][][]byte, error) {
+	if len(customCharsets) > maxCustomCharsets {
+		return nil, fmt.Errorf("at most %d custom charsets are supported, got %d: %w", maxCustomCharsets, len(customCharsets), model.ErrInvalidMask)
+	}
+
+	custom := make([][][]byte, len(customCharsets))
+	for i, charset := range customCharsets {
+		symbols, err := expandCharset(charset, nil)
+		if err != nil {
+			return nil, fmt.Errorf("custom charset %d: %w", i+1, err)
+		}
+
+		if len(symbols) == 0 {
+			return nil, fmt.Errorf("custom charset %d is empty: %w", i+1, model.ErrInvalidMask)
+		}
+
+		custom[i] = symbols
+	}
+
+	charsets := make([][][]byte, 0, len(mask))
+
+	for i := 0; i < len(mask); {
+		r, size := utf8.DecodeRuneInString(mask[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return nil, fmt.Errorf("mask is not valid utf-8: %w", model.ErrInvalidMask)
+		}
+
+		if r != '?' {
+			charsets = append(charsets, [][]byte{[]byte(mask[i : i+size])})
+			i += size
+			continue
+		}
+
+		if i+1 >= len(mask) {
+			return nil, fmt.Errorf("mask ends with a dangling '?': %w", model.ErrInvalidMask)
+		}
+
+		symbols, err := placeholderCharset(rune(mask[i+1]), custom)
+		if err != nil {
+			return nil, err
+		}
+
+		charsets = append(charsets, symbols)
+		i += 2
+	}
+
+	if len(charsets) == 0 {
+		return nil, fmt.Errorf("mask is empty: %w", model.ErrInvalidMask)
+	}
+
+	return charsets, nil
+}
+
+func placeholderCharset(placeholder rune, custom [][][]byte) ([][]byte, error) {
+	switch {
+	case placeholder == '?':
+		return [][]byte{{'?'}}, nil
+	case placeholder == 'a':
+		return literalCharset(builtinCharsets['l'] + builtinCharsets['u'] + builtinCharsets['d'] + builtinCharsets['s']), nil
+	case placeholder == 'b':
+		symbols := make([][]byte, 256)
+		for i := range symbols {
+			symbols[i] = []byte{byte(i)}
+		}
+		return symbols, nil
+	case placeholder >= '1' && placeholder <= '9':
+		idx := int(placeholder - '1')
+		if idx >= len(custom) {
+			return nil, fmt.Errorf("custom charset ?%c is not defined: %w", placeholder, model.ErrInvalidMask)
+		}
+		return custom[idx], nil
+	}
+
+	charset, ok := builtinCharsets[placeholder]
+	if !ok {
+		return nil, fmt.Errorf("unknown placeholder ?%c: %w", placeholder, model.ErrInvalidMask)
+	}
+
+	return literalCharset(charset), nil
+}
+
+func literalCharset(charset string) [][]byte {
+	symbols := make([][]byte, 0, len(charset))
+	for _, r := range charset {
+		symbols = append(symbols, utf8.AppendRune(nil, r))
+	}
+
+	return symbols
+}
+
+func expandCharset(charset string, custom [][][]byte) ([][]byte, error) {
+	symbols := make([][]byte, 0, len(charset))
+	visited := make(map[string]bool)
+
+	add := func(symbol []byte) {
+		if !visited[string(symbol)] {
+			visited[string(symbol)] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	for i := 0; i < len(charset); {
+		r, size := utf8.DecodeRuneInString(charset[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return nil, fmt.Errorf("charset is not valid utf-8: %w", model.ErrInvalidMask)
+		}
+
+		if r != '?' {
+			add([]byte(charset[i : i+size]))
+			i += size
+			continue
+		}
+
+		if i+1 >= len(charset) {
+			return nil, fmt.Errorf("charset ends with a dangling '?': %w", model.ErrInvalidMask)
+		}
+
+		expanded, err := placeholderCharset(rune(charset[i+1]), custom)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, symbol := range expanded {
+			add(symbol)
+		}
+
+		i += 2
+	}
+
+	return symbols, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMaskGenerator_Next(t *testing.T) {
+	charsets, err := parseMask("P?d?1", []string{"xy"})
+	assert.NoError(t, err)
+
+	size, fits := MaskKeyspaceSize(charsets)
+	assert.True(t, fits)
+	assert.Equal(t, uint64(20), size)
+
+	g := NewMaskGenerator(charsets, 17, 3)
+
+	words := make([]string, 0)
+	for g.HasNext() {
+		words = append(words, g.Next())
+	}
+
+	assert.Equal(t, []string{"P8y", "P9x", "P9y"}, words)
+}
+
+func TestParseMask(t *testing.T) {
+	tests := []struct {
+		mask     string
+		custom   []string
+		expected []int
+	}{
+		{"?u?l?l?l?d?d", nil, []int{26, 26, 26, 26, 10, 10}},
+		{"Pass?d?d?d", nil, []int{1, 1, 1, 1, 10, 10, 10}},
+		{"???a?b", nil, []int{1, 95, 256}},
+		{"?1?2", []string{"?l?d", "абв"}, []int{36, 3}},
+	}
+
+	for _, test := range tests {
+		charsets, err := parseMask(test.mask, test.custom)
+		assert.NoError(t, err)
+
+		sizes := make([]int, len(charsets))
+		for i, charset := range charsets {
+			sizes[i] = len(charset)
+		}
+
+		assert.Equal(t, test.expected, sizes)
+	}
+}
+
+func TestParseMask_Invalid(t *testing.T) {
+	masks := []string{"", "abc?", "?x", "?1"}
+
+	for _, mask := range masks {
+		_, err := parseMask(mask, nil)
+		assert.Error(t, err, mask)
+	}
+}
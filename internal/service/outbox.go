@@ -0,0 +1,497 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/crack-hash-worker/internal/metrics"
+	"github.com/fatalistix/slogattr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	outboxPendingSuffix   = ".json"
+	outboxDeliveredSuffix = ".delivered"
+)
+
+type outboxRecord struct {
+	Request     client.CompleteRequest `json:"request"`
+	Fingerprint string                 `json:"fingerprint,omitempty"`
+	Trace       map[string]string      `json:"trace,omitempty"`
+}
+
+type deliveredReport struct {
+	record      outboxRecord
+	deliveredAt time.Time
+}
+
+type outboxEntry struct {
+	record      outboxRecord
+	attempts    int
+	nextAttempt time.Time
+}
+
+type workerIdentity interface {
+	WorkerId() (string, bool)
+}
+
+type Outbox struct {
+	log            *slog.Logger
+	dir            string
+	managerAddress string
+	identity       workerIdentity
+	completer      *client.Completer
+	metrics        *metrics.Metrics
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	mu             sync.Mutex
+	pending        map[string]*outboxEntry
+	delivered      map[string]deliveredReport
+	wake           chan struct{}
+	stop           chan struct{}
+	stopOnce       sync.Once
+	done           chan struct{}
+}
+
+func NewOutbox(
+	log *slog.Logger,
+	dir string,
+	managerAddress string,
+	identity workerIdentity,
+	minBackoff time.Duration,
+	maxBackoff time.Duration,
+	metrics *metrics.Metrics,
+) (*Outbox, error) {
+	const op = "service.NewOutbox"
+
+	o := &Outbox{
+		log:            log,
+		dir:            dir,
+		managerAddress: managerAddress,
+		identity:       identity,
+		completer:      client.NewCompleter(log),
+		metrics:        metrics,
+		minBackoff:     max(minBackoff, time.Millisecond),
+		maxBackoff:     max(maxBackoff, minBackoff),
+		pending:        make(map[string]*outboxEntry),
+		delivered:      make(map[string]deliveredReport),
+		wake:           make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: error creating outbox directory %q: %w", op, dir, err)
+	}
+
+	if err := o.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("outbox loaded", slog.String("dir", dir), slog.Int("pending", len(o.pending)))
+
+	go o.run()
+
+	return o, nil
+}
+
+func (o *Outbox) Enqueue(ctx context.Context, request client.CompleteRequest, fingerprint string) error {
+	const op = "service.Outbox.Enqueue"
+
+	log := o.log.With(
+		slog.String("op", op),
+		slog.String("task_id", request.TaskId),
+	)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.pending[request.TaskId]; ok {
+		log.Warn("completion report already pending, dropping duplicate")
+		return nil
+	}
+
+	if _, ok := o.delivered[request.TaskId]; ok {
+		log.Warn("completion report already delivered, dropping duplicate")
+		return nil
+	}
+
+	record := outboxRecord{
+		Request:     request,
+		Fingerprint: fingerprint,
+		Trace:       make(map[string]string),
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(record.Trace))
+
+	if err := writeFileAtomic(o.pendingPath(request.TaskId), record); err != nil {
+		log.Error("error persisting completion report", slogattr.Err(err))
+		return fmt.Errorf("%s: error persisting completion report for task %q: %w", op, request.TaskId, err)
+	}
+
+	o.pending[request.TaskId] = &outboxEntry{
+		record:      record,
+		nextAttempt: time.Now(),
+	}
+
+	o.notify()
+
+	return nil
+}
+
+func (o *Outbox) report(taskId string) (outboxRecord, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if entry, ok := o.pending[taskId]; ok {
+		return entry.record, true
+	}
+
+	o.prune()
+
+	delivered, ok := o.delivered[taskId]
+	return delivered.record, ok
+}
+
+func (o *Outbox) prune() {
+	now := time.Now()
+	for taskId, delivered := range o.delivered {
+		if now.Sub(delivered.deliveredAt) <= finishedTaskRetention {
+			continue
+		}
+
+		delete(o.delivered, taskId)
+		if err := os.Remove(o.deliveredPath(taskId)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			o.log.Error("error removing delivered completion report marker", slog.String("task_id", taskId), slogattr.Err(err))
+		}
+	}
+}
+
+func (o *Outbox) Drain(ctx context.Context) error {
+	const op = "service.Outbox.Drain"
+
+	o.stopLoop()
+
+	workerId, ok := o.identity.WorkerId()
+	if !ok {
+		o.log.Warn("worker is not registered, leaving completion reports in outbox", slog.String("op", op))
+		return o.undelivered(op)
+	}
+
+	o.mu.Lock()
+	pending := make([]outboxRecord, 0, len(o.pending))
+	for _, entry := range o.pending {
+		pending = append(pending, entry.record)
+	}
+	o.mu.Unlock()
+
+	for _, record := range pending {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+
+		o.deliver(ctx, workerId, record)
+	}
+
+	return o.undelivered(op)
+}
+
+func (o *Outbox) undelivered(op string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.pending) > 0 {
+		return fmt.Errorf("%s: %d completion reports left undelivered", op, len(o.pending))
+	}
+
+	return nil
+}
+
+func (o *Outbox) Close() error {
+	o.stopLoop()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.pending) > 0 {
+		o.log.Warn("outbox closed with undelivered completion reports", slog.Int("pending", len(o.pending)))
+	}
+
+	return nil
+}
+
+func (o *Outbox) stopLoop() {
+	o.stopOnce.Do(func() {
+		close(o.stop)
+	})
+	<-o.done
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) run() {
+	defer close(o.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-timer.C:
+		}
+
+		if !o.deliverDue() {
+			timer.Reset(o.minBackoff)
+			continue
+		}
+
+		if next, ok := o.nextAttempt(); ok {
+			timer.Reset(max(time.Until(next), 0))
+		}
+	}
+}
+
+func (o *Outbox) deliverDue() bool {
+	workerId, ok := o.identity.WorkerId()
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+
+	o.mu.Lock()
+	due := make([]outboxRecord, 0)
+	for _, entry := range o.pending {
+		if !entry.nextAttempt.After(now) {
+			due = append(due, entry.record)
+		}
+	}
+	o.mu.Unlock()
+
+	for _, record := range due {
+		select {
+		case <-o.stop:
+			return true
+		default:
+		}
+
+		o.deliver(context.Background(), workerId, record)
+	}
+
+	return true
+}
+
+func (o *Outbox) deliver(ctx context.Context, workerId string, record outboxRecord) {
+	request := record.Request
+	request.WorkerId = workerId
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(record.Trace))
+	ctx, span := tracer.Start(ctx, "complete", trace.WithAttributes(
+		attribute.String("task.id", request.TaskId),
+		attribute.String("task.status", request.Status),
+	))
+
+	err := o.completer.Complete(ctx, o.managerAddress, request)
+	o.metrics.CompletionReported(err)
+
+	if err != nil {
+		endSpanWithError(span, err)
+	} else {
+		span.End()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err != nil {
+		o.reschedule(request.TaskId, err)
+	} else {
+		o.markDelivered(record)
+	}
+}
+
+func (o *Outbox) reschedule(taskId string, err error) {
+	entry, ok := o.pending[taskId]
+	if !ok {
+		return
+	}
+
+	entry.attempts++
+	delay := backoff(o.minBackoff, o.maxBackoff, entry.attempts)
+	entry.nextAttempt = time.Now().Add(delay)
+
+	o.log.Warn(
+		"completion report not delivered, retrying later",
+		slog.String("task_id", taskId),
+		slog.Int("attempts", entry.attempts),
+		slog.Duration("retry in", delay),
+		slogattr.Err(err),
+	)
+}
+
+func (o *Outbox) markDelivered(record outboxRecord) {
+	taskId := record.Request.TaskId
+	record.Trace = nil
+
+	o.prune()
+
+	delete(o.pending, taskId)
+	o.delivered[taskId] = deliveredReport{
+		record:      record,
+		deliveredAt: time.Now(),
+	}
+
+	if err := writeFileAtomic(o.deliveredPath(taskId), record); err != nil {
+		o.log.Error("error recording delivered completion report", slog.String("task_id", taskId), slogattr.Err(err))
+	}
+
+	if err := os.Remove(o.pendingPath(taskId)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		o.log.Error("error removing delivered completion report", slog.String("task_id", taskId), slogattr.Err(err))
+	}
+
+	o.log.Info("completion report delivered", slog.String("task_id", taskId))
+}
+
+func (o *Outbox) nextAttempt() (time.Time, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var next time.Time
+	for _, entry := range o.pending {
+		if next.IsZero() || entry.nextAttempt.Before(next) {
+			next = entry.nextAttempt
+		}
+	}
+
+	return next, !next.IsZero()
+}
+
+func (o *Outbox) load() error {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return fmt.Errorf("error reading outbox directory %q: %w", o.dir, err)
+	}
+
+	now := time.Now()
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(o.dir, name)
+
+		switch {
+		case strings.HasSuffix(name, tempFileSuffix):
+			_ = os.Remove(path)
+		case strings.HasSuffix(name, outboxDeliveredSuffix):
+			taskId, ok := decodeTaskId(strings.TrimSuffix(name, outboxDeliveredSuffix))
+			if !ok {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			if now.Sub(info.ModTime()) > finishedTaskRetention {
+				_ = os.Remove(path)
+				continue
+			}
+
+			record := outboxRecord{Request: client.CompleteRequest{TaskId: taskId}}
+			if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+				if decoded, err := decodeOutboxRecord(data); err == nil {
+					record = decoded
+				}
+			}
+
+			o.delivered[taskId] = deliveredReport{
+				record:      record,
+				deliveredAt: info.ModTime(),
+			}
+		case strings.HasSuffix(name, outboxPendingSuffix):
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("error reading completion report %q: %w", path, err)
+			}
+
+			record, err := decodeOutboxRecord(data)
+			if err != nil {
+				o.log.Error("dropping corrupted completion report", slog.String("path", path), slogattr.Err(err))
+				_ = os.Remove(path)
+				continue
+			}
+
+			o.pending[record.Request.TaskId] = &outboxEntry{
+				record:      record,
+				nextAttempt: now,
+			}
+		}
+	}
+
+	for taskId := range o.pending {
+		if _, ok := o.delivered[taskId]; ok {
+			delete(o.pending, taskId)
+			_ = os.Remove(o.pendingPath(taskId))
+		}
+	}
+
+	return nil
+}
+
+func decodeOutboxRecord(data []byte) (outboxRecord, error) {
+	var record outboxRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return outboxRecord{}, err
+	}
+
+	if record.Request.TaskId != "" {
+		return record, nil
+	}
+
+	if err := json.Unmarshal(data, &record.Request); err != nil {
+		return outboxRecord{}, err
+	}
+
+	if record.Request.TaskId == "" {
+		return outboxRecord{}, errors.New("completion report has no task id")
+	}
+
+	return record, nil
+}
+
+func (o *Outbox) pendingPath(taskId string) string {
+	return filepath.Join(o.dir, encodeTaskId(taskId)+outboxPendingSuffix)
+}
+
+func (o *Outbox) deliveredPath(taskId string) string {
+	return filepath.Join(o.dir, encodeTaskId(taskId)+outboxDeliveredSuffix)
+}
+
+func encodeTaskId(taskId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(taskId))
+}
+
+func decodeTaskId(encoded string) (string, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	return string(decoded), err == nil
+}
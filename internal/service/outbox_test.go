@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/crack-hash-worker/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type staticIdentity string
+
+func (i staticIdentity) WorkerId() (string, bool) {
+	return string(i), true
+}
+
+func TestOutbox_RetryAndRestart(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+
+	var attempts, delivered atomic.Int64
+	manager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer manager.Close()
+
+	address := strings.TrimPrefix(manager.URL, "http://")
+
+	offline, err := NewOutbox(log, dir, "127.0.0.1:1", staticIdentity("w1"), time.Hour, time.Hour, metrics.New())
+	assert.NoError(t, err)
+	assert.NoError(t, offline.Enqueue(context.Background(), client.CompleteRequest{TaskId: "t1", Status: "completed"}, "fp"))
+	assert.NoError(t, offline.Close())
+
+	outbox, err := NewOutbox(log, dir, address, staticIdentity("w1"), time.Millisecond, 10*time.Millisecond, metrics.New())
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return delivered.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(3), attempts.Load())
+
+	assert.NoError(t, outbox.Enqueue(context.Background(), client.CompleteRequest{TaskId: "t1"}, ""))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(1), delivered.Load())
+	assert.NoError(t, outbox.Close())
+
+	restarted, err := NewOutbox(log, dir, "127.0.0.1:1", staticIdentity("w1"), time.Hour, time.Hour, metrics.New())
+	assert.NoError(t, err)
+	record, ok := restarted.report("t1")
+	assert.True(t, ok)
+	assert.Equal(t, "fp", record.Fingerprint)
+	assert.Equal(t, "completed", record.Request.Status)
+	assert.NoError(t, restarted.Close())
+
+	expired := time.Now().Add(-finishedTaskRetention - time.Minute)
+	assert.NoError(t, os.Chtimes(restarted.deliveredPath("t1"), expired, expired))
+
+	restarted, err = NewOutbox(log, dir, "127.0.0.1:1", staticIdentity("w1"), time.Hour, time.Hour, metrics.New())
+	assert.NoError(t, err)
+	defer restarted.Close()
+	_, ok = restarted.report("t1")
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts := 1; attempts < 100; attempts++ {
+		delay := backoff(time.Second, time.Minute, attempts)
+		assert.LessOrEqual(t, delay, time.Minute)
+		assert.GreaterOrEqual(t, delay, time.Second/2)
+	}
+}
@@ -1,45 +1,62 @@
 package service
 
-import "strings"
+import (
+	"math"
+	"math/bits"
+)
 
 type PermutationGenerator struct {
-	current  []uint64
-	alphabet []rune
-	id       uint64
-	size     uint64
+	current   []uint64
+	alphabet  [][]byte
+	id        uint64
+	size      uint64
+	maxLength uint64
 }
 
-func NewPermutationGenerator(alphabet string, n, size uint64) *PermutationGenerator {
-	wordLen := countWordLen(alphabet, n)
-	current := nthCombination(alphabet, n, wordLen)
+func NewPermutationGenerator(alphabet [][]byte, n, size, maxLength uint64) *PermutationGenerator {
+	base := uint64(len(alphabet))
+	wordLen := countWordLen(base, n)
+	current := nthCombination(base, n, wordLen)
 
 	return &PermutationGenerator{
-		alphabet: []rune(alphabet),
-		current:  current,
-		id:       0,
-		size:     size,
+		alphabet:  alphabet,
+		current:   current,
+		id:        0,
+		size:      size,
+		maxLength: maxLength,
 	}
 }
 
-func countWordLen(alphabet string, n uint64) uint64 {
-	base := uint64(len(alphabet))
+func KeyspaceSize(alphabetSize, maxLength uint64) (uint64, bool) {
+	if alphabetSize == 1 {
+		return maxLength, true
+	}
+
+	return sumOfPowers(alphabetSize, maxLength)
+}
+
+func countWordLen(base, n uint64) uint64 {
 	sum := uint64(0)
 	length := uint64(1)
 	power := base
 
 	for {
-		sum += power
-		if n < sum {
+		var overflow bool
+		sum, overflow = addChecked(sum, power)
+		if overflow || n < sum {
 			return length
 		}
 		length++
-		power *= base
+		power, overflow = mulChecked(power, base)
+		if overflow {
+			return length
+		}
 	}
 }
 
-func nthCombination(alphabet string, n, length uint64) []uint64 {
-	base := uint64(len(alphabet))
-	n -= sumOfPowers(base, length-1)
+func nthCombination(base, n, length uint64) []uint64 {
+	prefix, _ := sumOfPowers(base, length-1)
+	n -= prefix
 	result := make([]uint64, length)
 
 	for i := length; i > 0; i-- {
@@ -50,18 +67,40 @@ func nthCombination(alphabet string, n, length uint64) []uint64 {
 	return result
 }
 
-func sumOfPowers(base, maxExp uint64) uint64 {
+func sumOfPowers(base, maxExp uint64) (uint64, bool) {
 	sum := uint64(0)
 	power := base
 	for i := uint64(0); i < maxExp; i++ {
-		sum += power
-		power *= base
+		var overflow bool
+		if sum, overflow = addChecked(sum, power); overflow {
+			return math.MaxUint64, false
+		}
+		if i+1 == maxExp {
+			break
+		}
+		if power, overflow = mulChecked(power, base); overflow {
+			return math.MaxUint64, false
+		}
 	}
-	return sum
+	return sum, true
+}
+
+func addChecked(a, b uint64) (uint64, bool) {
+	sum, carry := bits.Add64(a, b, 0)
+	return sum, carry != 0
+}
+
+func mulChecked(a, b uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	return lo, hi != 0
 }
 
 func (g *PermutationGenerator) Next() string {
-	result := g.makeResult()
+	return string(g.AppendNext(nil))
+}
+
+func (g *PermutationGenerator) AppendNext(dst []byte) []byte {
+	dst = g.appendCurrent(dst)
 
 	lastIdx := len(g.current) - 1
 	alphabetLen := uint64(len(g.alphabet))
@@ -90,18 +129,17 @@ func (g *PermutationGenerator) Next() string {
 	}
 
 	g.id++
-	return result
+	return dst
 }
 
-func (g *PermutationGenerator) makeResult() string {
-	builder := strings.Builder{}
+func (g *PermutationGenerator) appendCurrent(dst []byte) []byte {
 	for i := 0; i < len(g.current); i++ {
-		builder.WriteRune(g.alphabet[g.current[i]])
+		dst = append(dst, g.alphabet[g.current[i]]...)
 	}
 
-	return builder.String()
+	return dst
 }
 
 func (g *PermutationGenerator) HasNext() bool {
-	return g.id < g.size
+	return g.id < g.size && uint64(len(g.current)) <= g.maxLength
 }
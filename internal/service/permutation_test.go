@@ -1,7 +1,10 @@
 package service
 
 import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
 	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -19,10 +22,11 @@ func TestPermutationGenerator_Next(t *testing.T) {
 
 	for _, test := range tests {
 		g := PermutationGenerator{
-			alphabet: []rune(test.alphabet),
-			current:  test.current,
-			id:       0,
-			size:     2,
+			alphabet:  mustDecodeAlphabet(t, test.alphabet, model.AlphabetEncodingUTF8),
+			current:   test.current,
+			id:        0,
+			size:      2,
+			maxLength: 3,
 		}
 
 		assert.True(t, g.HasNext())
@@ -34,3 +38,114 @@ func TestPermutationGenerator_Next(t *testing.T) {
 		assert.Equal(t, test.expected, next)
 	}
 }
+
+func TestPermutationGenerator_MaxLength(t *testing.T) {
+	g := NewPermutationGenerator(mustDecodeAlphabet(t, "ab", model.AlphabetEncodingUTF8), 4, 10, 2)
+
+	words := make([]string, 0)
+	for g.HasNext() {
+		words = append(words, g.Next())
+	}
+
+	assert.Equal(t, []string{"ba", "bb"}, words)
+}
+
+func TestPermutationGenerator_Unicode(t *testing.T) {
+	g := NewPermutationGenerator(mustDecodeAlphabet(t, "абв", model.AlphabetEncodingUTF8), 2, 4, 2)
+
+	words := make([]string, 0)
+	for g.HasNext() {
+		words = append(words, g.Next())
+	}
+
+	assert.Equal(t, []string{"в", "аа", "аб", "ав"}, words)
+}
+
+func TestPermutationGenerator_Bytes(t *testing.T) {
+	g := NewPermutationGenerator(mustDecodeAlphabet(t, "00ff", model.AlphabetEncodingHex), 0, 4, 2)
+
+	words := make([][]byte, 0)
+	for g.HasNext() {
+		words = append(words, g.AppendNext(nil))
+	}
+
+	assert.Equal(t, [][]byte{{0x00}, {0xff}, {0x00, 0x00}, {0x00, 0xff}}, words)
+}
+
+func TestKeyspaceSize(t *testing.T) {
+	tests := []struct {
+		alphabet  string
+		maxLength uint64
+		expected  uint64
+	}{
+		{"a", 5, 5},
+		{"ab", 1, 2},
+		{"ab", 3, 14},
+		{"абв", 2, 12},
+		{"abcdefghijklmnopqrstuvwxyz1234567890", 4, 36 + 36*36 + 36*36*36 + 36*36*36*36},
+	}
+
+	for _, test := range tests {
+		alphabet := mustDecodeAlphabet(t, test.alphabet, model.AlphabetEncodingUTF8)
+		size, fits := KeyspaceSize(uint64(len(alphabet)), test.maxLength)
+		assert.True(t, fits)
+		assert.Equal(t, test.expected, size)
+	}
+}
+
+func TestKeyspaceSize_Overflow(t *testing.T) {
+	_, fits := KeyspaceSize(95, 9)
+	assert.True(t, fits)
+
+	_, fits = KeyspaceSize(95, 10)
+	assert.False(t, fits)
+
+	_, fits = KeyspaceSize(1, math.MaxUint64)
+	assert.True(t, fits)
+}
+
+func TestPermutationGenerator_LargeIndex(t *testing.T) {
+	alphabet := make([][]byte, 95)
+	for i := range alphabet {
+		alphabet[i] = []byte{byte(' ' + i)}
+	}
+
+	g := NewPermutationGenerator(alphabet, math.MaxUint64-1, 1, 10)
+
+	assert.True(t, g.HasNext())
+	word := g.AppendNext(nil)
+
+	assert.Len(t, word, 10)
+	assert.Equal(t, expectedWordAt(alphabet, math.MaxUint64-1), word)
+}
+
+func expectedWordAt(alphabet [][]byte, n uint64) []byte {
+	base := new(big.Int).SetInt64(int64(len(alphabet)))
+	index := new(big.Int).SetUint64(n)
+	power := new(big.Int).Set(base)
+	length := 1
+
+	for index.Cmp(power) >= 0 {
+		index.Sub(index, power)
+		power.Mul(power, base)
+		length++
+	}
+
+	word := make([]byte, length)
+	digit := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		index.DivMod(index, base, digit)
+		word[i] = alphabet[digit.Int64()][0]
+	}
+
+	return word
+}
+
+func mustDecodeAlphabet(t *testing.T, alphabet, encoding string) [][]byte {
+	symbols, err := decodeAlphabet(alphabet, encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return symbols
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type partProgress struct {
+	task       *taskProgress
+	index      int
+	start      uint64
+	end        uint64
+	state      atomic.Value
+	tested     atomic.Uint64
+	startedAt  atomic.Int64
+	finishedAt atomic.Int64
+	mu         sync.Mutex
+	matches    map[string][]string
+}
+
+func (p *partProgress) run() {
+	p.state.Store(model.PartStateRunning)
+	p.startedAt.Store(time.Now().UnixNano())
+}
+
+func (p *partProgress) match(hash, word string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.matches == nil {
+		p.matches = make(map[string][]string)
+	}
+	p.matches[hash] = append(p.matches[hash], word)
+}
+
+func (p *partProgress) update(tested uint64) {
+	p.tested.Store(tested)
+}
+
+func (p *partProgress) finish(state string, tested uint64) {
+	p.tested.Store(tested)
+	p.finishedAt.Store(time.Now().UnixNano())
+	p.state.Store(state)
+
+	if p.task != nil {
+		p.task.fold(p)
+	}
+}
+
+func (p *partProgress) snapshot(now time.Time) model.PartProgress {
+	state, _ := p.state.Load().(string)
+	tested := p.tested.Load()
+
+	progress := model.PartProgress{
+		Index:   p.index,
+		State:   state,
+		Start:   p.start,
+		End:     p.end,
+		Current: p.start + tested,
+		Tested:  tested,
+	}
+
+	startedAt := p.startedAt.Load()
+	if startedAt == 0 {
+		return progress
+	}
+
+	until := now.UnixNano()
+	if finishedAt := p.finishedAt.Load(); finishedAt != 0 {
+		until = finishedAt
+	}
+
+	if elapsed := time.Duration(until - startedAt); elapsed > 0 {
+		progress.HashesPerSecond = float64(tested) / elapsed.Seconds()
+	}
+
+	if state == model.PartStateRunning {
+		progress.Eta = eta(p.end-progress.Current, progress.HashesPerSecond)
+	}
+
+	return progress
+}
+
+type taskProgress struct {
+	mu            sync.Mutex
+	started       bool
+	dispatched    uint64
+	resumed       uint64
+	finishedParts uint64
+	finished      uint64
+	covered       []model.Range
+	matches       map[string][]string
+	parts         []*partProgress
+}
+
+func (p *taskProgress) begin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.started = true
+}
+
+func (p *taskProgress) dispatch(index int, part model.Part) *partProgress {
+	progress := &partProgress{
+		task:  p,
+		index: index,
+		start: part.Start,
+		end:   part.End,
+	}
+	progress.state.Store(model.PartStatePending)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.parts = append(p.parts, progress)
+	p.dispatched += part.End - part.Start
+
+	return progress
+}
+
+func (p *taskProgress) fold(part *partProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.Index(p.parts, part)
+	if i < 0 {
+		return
+	}
+	p.parts = slices.Delete(p.parts, i, i+1)
+
+	tested := part.tested.Load()
+	p.finishedParts++
+	p.finished += tested
+	if tested > 0 {
+		p.covered = mergeRanges(append(p.covered, model.Range{Start: part.start, End: part.start + tested}))
+	}
+
+	part.mu.Lock()
+	defer part.mu.Unlock()
+
+	for hash, words := range part.matches {
+		if p.matches == nil {
+			p.matches = make(map[string][]string)
+		}
+		p.matches[hash] = append(p.matches[hash], words...)
+	}
+}
+
+func (p *taskProgress) snapshot(task model.Task, withParts bool) model.TaskProgress {
+	p.mu.Lock()
+	started, dispatched, resumed := p.started, p.dispatched, p.resumed
+	finishedParts, finished := p.finishedParts, p.finished
+	parts := slices.Clone(p.parts)
+	p.mu.Unlock()
+
+	progress := model.TaskProgress{
+		RequestId:     task.RequestId,
+		TaskId:        task.TaskId,
+		State:         model.TaskStateQueued,
+		Start:         task.Start,
+		End:           task.End,
+		Dispatched:    dispatched,
+		Tested:        resumed + finished,
+		FinishedParts: finishedParts,
+	}
+
+	if started {
+		progress.State = model.TaskStateRunning
+	}
+
+	if withParts {
+		progress.Parts = make([]model.PartProgress, 0, len(parts))
+	}
+
+	now := time.Now()
+	for _, part := range parts {
+		snapshot := part.snapshot(now)
+
+		progress.Tested += snapshot.Tested
+		if snapshot.State == model.PartStateRunning {
+			progress.HashesPerSecond += snapshot.HashesPerSecond
+		}
+
+		if withParts {
+			progress.Parts = append(progress.Parts, snapshot)
+		}
+	}
+
+	progress.Eta = eta(task.End-task.Start-min(progress.Tested, task.End-task.Start), progress.HashesPerSecond)
+
+	return progress
+}
+
+func (p *taskProgress) resume(covered []model.Range) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range covered {
+		p.resumed += r.End - r.Start
+	}
+}
+
+func (p *taskProgress) checkpoint() ([]model.Range, map[string][]string) {
+	p.mu.Lock()
+	parts := slices.Clone(p.parts)
+	covered := slices.Clone(p.covered)
+	matches := make(map[string][]string, len(p.matches))
+	for hash, words := range p.matches {
+		matches[hash] = slices.Clone(words)
+	}
+	p.mu.Unlock()
+
+	for _, part := range parts {
+		if tested := part.tested.Load(); tested > 0 {
+			covered = append(covered, model.Range{Start: part.start, End: part.start + tested})
+		}
+
+		part.mu.Lock()
+		for hash, words := range part.matches {
+			matches[hash] = append(matches[hash], words...)
+		}
+		part.mu.Unlock()
+	}
+
+	return mergeRanges(covered), matches
+}
+
+func eta(remaining uint64, hashesPerSecond float64) time.Duration {
+	if hashesPerSecond <= 0 {
+		return 0
+	}
+
+	return time.Duration(float64(remaining) / hashesPerSecond * float64(time.Second))
+}
+
+func partState(part model.Part, matches map[string][]string, err error) string {
+	switch {
+	case err == nil && part.StopOnFirstMatch && len(matches) > 0:
+		return model.PartStateFound
+	case err == nil:
+		return model.PartStateCompleted
+	case errors.Is(err, context.DeadlineExceeded):
+		return model.PartStateTimedOut
+	case errors.Is(err, model.ErrTaskCancelled),
+		errors.Is(err, model.ErrMatchFound),
+		errors.Is(err, model.ErrShuttingDown),
+		errors.Is(err, model.ErrTaskExpired):
+		return model.PartStateCancelled
+	default:
+		return model.PartStateFailed
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTaskProgress_Snapshot(t *testing.T) {
+	task := model.Task{TaskId: "task", Start: 0, End: 300}
+
+	progress := new(taskProgress)
+	assert.Equal(t, model.TaskStateQueued, progress.snapshot(task, true).State)
+
+	progress.begin()
+	first := progress.dispatch(0, makePart(task, 0, 100))
+	second := progress.dispatch(1, makePart(task, 100, 200))
+
+	first.run()
+	first.finish(model.PartStateCompleted, 100)
+	second.run()
+	second.update(40)
+
+	snapshot := progress.snapshot(task, true)
+	assert.Equal(t, model.TaskStateRunning, snapshot.State)
+	assert.Equal(t, uint64(200), snapshot.Dispatched)
+	assert.Equal(t, uint64(140), snapshot.Tested)
+	assert.Equal(t, uint64(1), snapshot.FinishedParts)
+	assert.Len(t, snapshot.Parts, 1, "finished parts must be folded into counters")
+	assert.Equal(t, model.PartStateRunning, snapshot.Parts[0].State)
+	assert.Equal(t, uint64(140), snapshot.Parts[0].Current)
+
+	covered, _ := progress.checkpoint()
+	assert.Equal(t, []model.Range{{Start: 0, End: 140}}, covered)
+
+	assert.Nil(t, progress.snapshot(task, false).Parts)
+}
+
+func TestPartState(t *testing.T) {
+	part := model.Part{StopOnFirstMatch: true}
+	matches := map[string][]string{"h": {"a"}}
+
+	assert.Equal(t, model.PartStateFound, partState(part, matches, nil))
+	assert.Equal(t, model.PartStateCompleted, partState(part, nil, nil))
+	assert.Equal(t, model.PartStateTimedOut, partState(part, nil, context.DeadlineExceeded))
+	assert.Equal(t, model.PartStateCancelled, partState(part, nil, model.ErrTaskCancelled))
+	assert.Equal(t, model.PartStateFailed, partState(part, nil, model.ErrUnknownWordlist))
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"slices"
+)
+
+func sortRanges(ranges []model.Range) {
+	slices.SortFunc(ranges, func(a, b model.Range) int {
+		switch {
+		case a.Start < b.Start:
+			return -1
+		case a.Start > b.Start:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+func mergeRanges(ranges []model.Range) []model.Range {
+	sorted := slices.Clone(ranges)
+	sortRanges(sorted)
+
+	merged := make([]model.Range, 0, len(sorted))
+	for _, r := range sorted {
+		if r.Start >= r.End {
+			continue
+		}
+
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].End {
+			merged[last].End = max(merged[last].End, r.End)
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+func complementRanges(bounds model.Range, merged []model.Range) []model.Range {
+	complement := make([]model.Range, 0)
+
+	next := bounds.Start
+	for _, r := range merged {
+		start := max(r.Start, bounds.Start)
+		end := min(r.End, bounds.End)
+		if start >= end {
+			continue
+		}
+
+		if start > next {
+			complement = append(complement, model.Range{Start: next, End: start})
+		}
+		next = max(next, end)
+	}
+
+	if next < bounds.End {
+		complement = append(complement, model.Range{Start: next, End: bounds.End})
+	}
+
+	return complement
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/http/client"
+	"github.com/fatalistix/slogattr"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Registration struct {
+	log            *slog.Logger
+	registerer     *client.Registerer
+	managerAddress string
+	port           int
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	mu             sync.RWMutex
+	workerId       string
+	registered     bool
+}
+
+func NewRegistration(
+	log *slog.Logger,
+	managerAddress string,
+	port int,
+	minBackoff time.Duration,
+	maxBackoff time.Duration,
+) *Registration {
+	return &Registration{
+		log:            log,
+		registerer:     client.NewRegisterer(log),
+		managerAddress: managerAddress,
+		port:           port,
+		minBackoff:     max(minBackoff, time.Millisecond),
+		maxBackoff:     max(maxBackoff, minBackoff),
+	}
+}
+
+func (r *Registration) Register(ctx context.Context) error {
+	const op = "service.Registration.Register"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	for attempts := 1; ; attempts++ {
+		id, err := r.registerer.Register(ctx, r.managerAddress, r.port)
+		if err == nil {
+			r.mu.Lock()
+			r.workerId = id
+			r.registered = true
+			r.mu.Unlock()
+
+			log.Info("worker registered", slog.String("worker id", id), slog.Int("attempts", attempts))
+
+			return nil
+		}
+
+		delay := backoff(r.minBackoff, r.maxBackoff, attempts)
+
+		log.Warn(
+			"failed to register worker, retrying later",
+			slog.Int("attempts", attempts),
+			slog.Duration("retry in", delay),
+			slogattr.Err(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: giving up after %d attempts: %w", op, attempts, context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *Registration) Reregister(ctx context.Context) error {
+	r.mu.Lock()
+	r.registered = false
+	r.mu.Unlock()
+
+	r.log.Info("re-registering worker")
+
+	return r.Register(ctx)
+}
+
+func (r *Registration) Drain(ctx context.Context) error {
+	const op = "service.Registration.Drain"
+
+	r.mu.Lock()
+	workerId, registered := r.workerId, r.registered
+	r.registered = false
+	r.mu.Unlock()
+
+	if !registered {
+		return nil
+	}
+
+	if err := r.registerer.Deregister(ctx, r.managerAddress, workerId); err != nil {
+		r.log.Error("failed to deregister worker", slog.String("op", op), slogattr.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.log.Info("worker deregistered", slog.String("worker id", workerId))
+
+	return nil
+}
+
+func (r *Registration) WorkerId() (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.workerId, r.registered
+}
+
+func (r *Registration) Registered() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.registered
+}
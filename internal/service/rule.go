@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+)
+
+type ruleOp struct {
+	code byte
+	x    byte
+	y    byte
+	n    int
+}
+
+type Rule struct {
+	source string
+	ops    []ruleOp
+}
+
+func ParseRule(source string) (Rule, error) {
+	ops := make([]ruleOp, 0, len(source))
+
+	for i := 0; i < len(source); {
+		code := source[i]
+		i++
+
+		switch code {
+		case ' ':
+			continue
+		case ':', 'l', 'u', 'c', 'C', 't', 'r', 'd', '[', ']':
+			ops = append(ops, ruleOp{code: code})
+		case '$', '^':
+			if i >= len(source) {
+				return Rule{}, fmt.Errorf("rule %q: %c requires a character: %w", source, code, model.ErrInvalidRule)
+			}
+			ops = append(ops, ruleOp{code: code, x: source[i]})
+			i++
+		case 's':
+			if i+1 >= len(source) {
+				return Rule{}, fmt.Errorf("rule %q: s requires two characters: %w", source, model.ErrInvalidRule)
+			}
+			ops = append(ops, ruleOp{code: code, x: source[i], y: source[i+1]})
+			i += 2
+		case 'T', '\'':
+			if i >= len(source) {
+				return Rule{}, fmt.Errorf("rule %q: %c requires a position: %w", source, code, model.ErrInvalidRule)
+			}
+			n, ok := rulePosition(source[i])
+			if !ok {
+				return Rule{}, fmt.Errorf("rule %q: invalid position %q: %w", source, source[i], model.ErrInvalidRule)
+			}
+			ops = append(ops, ruleOp{code: code, n: n})
+			i++
+		default:
+			return Rule{}, fmt.Errorf("rule %q: unsupported function %q: %w", source, code, model.ErrInvalidRule)
+		}
+	}
+
+	if len(ops) == 0 {
+		return Rule{}, fmt.Errorf("rule %q is empty: %w", source, model.ErrInvalidRule)
+	}
+
+	return Rule{
+		source: source,
+		ops:    ops,
+	}, nil
+}
+
+func ParseRules(sources []string) ([]Rule, error) {
+	rules := make([]Rule, len(sources))
+
+	for i, source := range sources {
+		rule, err := ParseRule(source)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = rule
+	}
+
+	return rules, nil
+}
+
+func rulePosition(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, true
+	default:
+		return 0, false
+	}
+}
+
+func (r Rule) String() string {
+	return r.source
+}
+
+func (r Rule) Apply(dst, word []byte) []byte {
+	start := len(dst)
+	dst = append(dst, word...)
+
+	for _, op := range r.ops {
+		w := dst[start:]
+
+		switch op.code {
+		case 'l':
+			for i := range w {
+				w[i] = toLower(w[i])
+			}
+		case 'u':
+			for i := range w {
+				w[i] = toUpper(w[i])
+			}
+		case 'c':
+			for i := range w {
+				if i == 0 {
+					w[i] = toUpper(w[i])
+				} else {
+					w[i] = toLower(w[i])
+				}
+			}
+		case 'C':
+			for i := range w {
+				if i == 0 {
+					w[i] = toLower(w[i])
+				} else {
+					w[i] = toUpper(w[i])
+				}
+			}
+		case 't':
+			for i := range w {
+				w[i] = toggleCase(w[i])
+			}
+		case 'T':
+			if op.n < len(w) {
+				w[op.n] = toggleCase(w[op.n])
+			}
+		case 'r':
+			for i, j := 0, len(w)-1; i < j; i, j = i+1, j-1 {
+				w[i], w[j] = w[j], w[i]
+			}
+		case 'd':
+			dst = append(dst, w...)
+		case '$':
+			dst = append(dst, op.x)
+		case '^':
+			dst = append(dst, 0)
+			copy(dst[start+1:], dst[start:len(dst)-1])
+			dst[start] = op.x
+		case 's':
+			for i := range w {
+				if w[i] == op.x {
+					w[i] = op.y
+				}
+			}
+		case '\'':
+			if op.n < len(w) {
+				dst = dst[:start+op.n]
+			}
+		case '[':
+			if len(w) > 0 {
+				copy(w, w[1:])
+				dst = dst[:len(dst)-1]
+			}
+		case ']':
+			if len(w) > 0 {
+				dst = dst[:len(dst)-1]
+			}
+		}
+	}
+
+	return dst
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func toUpper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func toggleCase(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+type RuleGenerator struct {
+	words *DictionaryGenerator
+	rules []Rule
+	word  []byte
+	rule  int
+	id    uint64
+	size  uint64
+}
+
+func NewRuleGenerator(wordlist *Wordlist, rules []Rule, n, size uint64) (*RuleGenerator, error) {
+	count := uint64(len(rules))
+	offset := n % count
+	lines := size/count + (offset+size%count+count-1)/count
+
+	words, err := NewDictionaryGenerator(wordlist, n/count, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &RuleGenerator{
+		words: words,
+		rules: rules,
+		word:  make([]byte, 0, 64),
+		rule:  int(offset),
+		id:    0,
+		size:  size,
+	}
+
+	if g.rule != 0 && words.HasNext() {
+		g.word = words.AppendNext(g.word[:0])
+	}
+
+	return g, nil
+}
+
+func RuleKeyspaceSize(lines uint64, rules int) (uint64, bool) {
+	size, overflow := mulChecked(lines, uint64(rules))
+	return size, !overflow
+}
+
+func (g *RuleGenerator) AppendNext(dst []byte) []byte {
+	if g.rule == 0 {
+		g.word = g.words.AppendNext(g.word[:0])
+	}
+
+	dst = g.rules[g.rule].Apply(dst, g.word)
+
+	g.rule++
+	if g.rule == len(g.rules) {
+		g.rule = 0
+	}
+
+	g.id++
+	return dst
+}
+
+func (g *RuleGenerator) HasNext() bool {
+	if g.id >= g.size || g.words.Err() != nil {
+		return false
+	}
+
+	return g.rule != 0 || g.words.HasNext()
+}
+
+func (g *RuleGenerator) Err() error {
+	return g.words.Err()
+}
+
+func (g *RuleGenerator) Close() error {
+	return g.words.Close()
+}
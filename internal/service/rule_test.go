@@ -0,0 +1,78 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRule_Apply(t *testing.T) {
+	tests := []struct {
+		rule     string
+		word     string
+		expected string
+	}{
+		{":", "password", "password"},
+		{"u", "password", "PASSWORD"},
+		{"c", "pASSWORD", "Password"},
+		{"C", "password", "pASSWORD"},
+		{"t", "PassWord", "pASSwORD"},
+		{"T0T4", "password", "PassWord"},
+		{"r", "password", "drowssap"},
+		{"d", "pass", "passpass"},
+		{"$1$2$3", "pass", "pass123"},
+		{"^!^1", "pass", "1!pass"},
+		{"sa@so0ss$", "password", "p@$$w0rd"},
+		{"'4", "password", "pass"},
+		{"[]", "password", "asswor"},
+		{"c $2 $0 $2 $4", "summer", "Summer2024"},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseRule(test.rule)
+		assert.NoError(t, err, test.rule)
+
+		assert.Equal(t, test.expected, string(rule.Apply([]byte("prefix:"), []byte(test.word))[len("prefix:"):]), test.rule)
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	for _, source := range []string{"", "$", "s1", "Tz", "x"} {
+		_, err := ParseRule(source)
+		assert.ErrorIs(t, err, model.ErrInvalidRule, source)
+	}
+}
+
+func TestRuleGenerator_Next(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "words.txt"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewWordlistStore(slog.New(slog.NewTextHandler(io.Discard, nil)), dir, t.TempDir())
+	wordlist, err := store.Open("words.txt")
+	assert.NoError(t, err)
+
+	rules, err := ParseRules([]string{":", "u", "$1"})
+	assert.NoError(t, err)
+
+	size, fits := RuleKeyspaceSize(wordlist.Lines(), len(rules))
+	assert.True(t, fits)
+	assert.Equal(t, uint64(9), size)
+
+	g, err := NewRuleGenerator(wordlist, rules, 2, 5)
+	assert.NoError(t, err)
+	defer g.Close()
+
+	words := make([]string, 0)
+	for g.HasNext() {
+		words = append(words, string(g.AppendNext(nil)))
+	}
+
+	assert.NoError(t, g.Err())
+	assert.Equal(t, []string{"one1", "two", "TWO", "two1", "three"}, words)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/hasher"
+)
+
+type targetSet struct {
+	digests map[string]string
+	single  []byte
+}
+
+func newTargetSet(h hasher.Hasher, hashes []string) (targetSet, error) {
+	digests := make(map[string]string, len(hashes))
+
+	for _, hash := range hashes {
+		digest, err := hex.DecodeString(hash)
+		if err != nil {
+			return targetSet{}, fmt.Errorf("invalid %s hash %q: %w", h.Name(), hash, err)
+		}
+
+		if len(digest) != h.Size() {
+			return targetSet{}, fmt.Errorf("invalid %s hash %q: expected %d bytes, got %d", h.Name(), hash, h.Size(), len(digest))
+		}
+
+		digests[string(digest)] = hash
+	}
+
+	targets := targetSet{
+		digests: digests,
+	}
+
+	if len(digests) == 1 {
+		for digest := range digests {
+			targets.single = []byte(digest)
+		}
+	}
+
+	return targets, nil
+}
+
+func (t targetSet) lookup(digest []byte) (string, bool) {
+	if t.single != nil && !bytes.Equal(digest, t.single) {
+		return "", false
+	}
+
+	hash, ok := t.digests[string(digest)]
+	return hash, ok
+}
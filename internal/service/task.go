@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"go.opentelemetry.io/otel/trace"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type taskRun struct {
+	task       model.Task
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	throughput *throughput
+	progress   *taskProgress
+	span       trace.Span
+	resume     resumeState
+	claimed    atomic.Bool
+	// guarded by CheckpointStore.mu
+	checkpointDone bool
+}
+
+func (r *taskRun) claim() bool {
+	return r.claimed.CompareAndSwap(false, true)
+}
+
+type partJob struct {
+	run      *taskRun
+	index    int
+	part     model.Part
+	progress *partProgress
+}
+
+const finishedTaskRetention = 24 * time.Hour
+
+type finishedTask struct {
+	fingerprint string
+	progress    model.TaskProgress
+	finishedAt  time.Time
+}
+
+type taskRegistry struct {
+	mu       sync.Mutex
+	runs     map[string]*taskRun
+	finished map[string]finishedTask
+	now      func() time.Time
+}
+
+func newTaskRegistry() *taskRegistry {
+	return &taskRegistry{
+		runs:     make(map[string]*taskRun),
+		finished: make(map[string]finishedTask),
+		now:      time.Now,
+	}
+}
+
+func (r *taskRegistry) add(ctx context.Context, task model.Task) (*taskRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.runs[task.TaskId]; ok {
+		return nil, false
+	}
+
+	span := trace.SpanFromContext(ctx)
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	run := &taskRun{
+		task:       task,
+		ctx:        ctx,
+		cancel:     cancel,
+		throughput: new(throughput),
+		progress:   new(taskProgress),
+		span:       span,
+	}
+
+	r.runs[task.TaskId] = run
+
+	return run, true
+}
+
+func (r *taskRegistry) existing(taskId string) (string, model.TaskProgress, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run, ok := r.runs[taskId]; ok {
+		return taskFingerprint(run.task), run.progress.snapshot(run.task, false), true
+	}
+
+	r.prune()
+
+	if finished, ok := r.finished[taskId]; ok {
+		return finished.fingerprint, finished.progress, true
+	}
+
+	return "", model.TaskProgress{}, false
+}
+
+func (r *taskRegistry) finish(run *taskRun, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.cancel(nil)
+
+	if r.runs[run.task.TaskId] != run {
+		return
+	}
+
+	delete(r.runs, run.task.TaskId)
+
+	progress := run.progress.snapshot(run.task, false)
+	progress.State = status
+	progress.HashesPerSecond = 0
+	progress.Eta = 0
+
+	r.prune()
+	r.finished[run.task.TaskId] = finishedTask{
+		fingerprint: taskFingerprint(run.task),
+		progress:    progress,
+		finishedAt:  r.now(),
+	}
+}
+
+func (r *taskRegistry) tasks() []model.TaskProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+
+	tasks := make([]model.TaskProgress, 0, len(r.runs)+len(r.finished))
+	for _, run := range r.runs {
+		tasks = append(tasks, run.progress.snapshot(run.task, false))
+	}
+	for _, finished := range r.finished {
+		tasks = append(tasks, finished.progress)
+	}
+
+	slices.SortFunc(tasks, func(a, b model.TaskProgress) int {
+		return strings.Compare(a.TaskId, b.TaskId)
+	})
+
+	return tasks
+}
+
+func (r *taskRegistry) prune() {
+	now := r.now()
+	for taskId, finished := range r.finished {
+		if now.Sub(finished.finishedAt) > finishedTaskRetention {
+			delete(r.finished, taskId)
+		}
+	}
+}
+
+func (r *taskRegistry) get(taskId string) (*taskRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[taskId]
+	return run, ok
+}
+
+func (r *taskRegistry) list() []*taskRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]*taskRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+
+	slices.SortFunc(runs, func(a, b *taskRun) int {
+		return strings.Compare(a.task.TaskId, b.task.TaskId)
+	})
+
+	return runs
+}
+
+func (r *taskRegistry) taskIds() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.runs))
+	for id := range r.runs {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
+
+func (r *taskRegistry) cancelAll(cause error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, run := range r.runs {
+		run.cancel(cause)
+	}
+}
+
+func (r *taskRegistry) removeRun(run *taskRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.cancel(nil)
+
+	if r.runs[run.task.TaskId] == run {
+		delete(r.runs, run.task.TaskId)
+	}
+}
+
+func taskFingerprint(task model.Task) string {
+	data, _ := json.Marshal(task)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+)
+
+var tracer = otel.Tracer("github.com/fatalistix/crack-hash-worker/internal/service")
+
+func taskAttributes(task model.Task) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("task.request_id", task.RequestId),
+		attribute.String("task.id", task.TaskId),
+		attribute.String("task.mode", task.Mode),
+		attribute.String("task.algorithm", task.Algorithm),
+		attribute.String("task.start", strconv.FormatUint(task.Start, 10)),
+		attribute.String("task.end", strconv.FormatUint(task.End, 10)),
+	}
+}
+
+func endTaskSpan(run *taskRun, status string) {
+	run.span.SetAttributes(attribute.String("task.status", status))
+	if status == model.TaskStatusPartial || status == model.TaskStatusExpired {
+		run.span.SetStatus(codes.Error, status)
+	}
+	run.span.End()
+}
+
+func endSpanWithError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
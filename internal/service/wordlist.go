@@ -0,0 +1,248 @@
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/fatalistix/slogattr"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	wordlistIndexSuffix = ".idx"
+	offsetSize          = 8
+)
+
+type Wordlist struct {
+	Name      string
+	path      string
+	indexPath string
+	lines     uint64
+}
+
+type wordlistEntry struct {
+	done     chan struct{}
+	modTime  time.Time
+	wordlist *Wordlist
+	err      error
+}
+
+type WordlistStore struct {
+	dir       string
+	indexDir  string
+	log       *slog.Logger
+	mu        sync.Mutex
+	wordlists map[string]*wordlistEntry
+}
+
+func NewWordlistStore(log *slog.Logger, dir, indexDir string) *WordlistStore {
+	return &WordlistStore{
+		dir:       dir,
+		indexDir:  indexDir,
+		log:       log,
+		wordlists: make(map[string]*wordlistEntry),
+	}
+}
+
+func (s *WordlistStore) Preload() {
+	const op = "service.WordlistStore.Preload"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Warn("unable to list wordlists", slogattr.Err(err))
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		if _, err := s.Open(entry.Name()); err != nil {
+			log.Error("error preloading wordlist", slog.String("wordlist", entry.Name()), slogattr.Err(err))
+		}
+	}
+}
+
+func (s *WordlistStore) Open(name string) (*Wordlist, error) {
+	const op = "service.WordlistStore.Open"
+
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("%s: invalid wordlist name %q: %w", op, name, model.ErrUnknownWordlist)
+	}
+
+	path := filepath.Join(s.dir, name)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !info.Mode().IsRegular()) {
+		return nil, fmt.Errorf("%s: wordlist %q not found: %w", op, name, model.ErrUnknownWordlist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading wordlist %q: %w", op, name, err)
+	}
+
+	s.mu.Lock()
+	entry, ok := s.wordlists[name]
+	if !ok || !entry.modTime.Equal(info.ModTime()) {
+		entry = &wordlistEntry{
+			done:    make(chan struct{}),
+			modTime: info.ModTime(),
+		}
+		s.wordlists[name] = entry
+		s.mu.Unlock()
+
+		entry.wordlist, entry.err = s.open(name, path, info)
+		close(entry.done)
+
+		if entry.err != nil {
+			s.mu.Lock()
+			if s.wordlists[name] == entry {
+				delete(s.wordlists, name)
+			}
+			s.mu.Unlock()
+		}
+	} else {
+		s.mu.Unlock()
+		<-entry.done
+	}
+
+	if entry.err != nil {
+		return nil, fmt.Errorf("%s: %w", op, entry.err)
+	}
+
+	return entry.wordlist, nil
+}
+
+func (s *WordlistStore) open(name, path string, info fs.FileInfo) (*Wordlist, error) {
+	const op = "service.WordlistStore.open"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("wordlist", name),
+	)
+
+	indexPath := filepath.Join(s.indexDir, name+wordlistIndexSuffix)
+
+	if !isIndexFresh(indexPath, info) {
+		log.Info("building wordlist index")
+
+		if err := buildWordlistIndex(path, indexPath); err != nil {
+			log.Error("error building wordlist index", slogattr.Err(err))
+			return nil, fmt.Errorf("%s: error building index for wordlist %q: %w", op, name, err)
+		}
+	}
+
+	indexInfo, err := os.Stat(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading index for wordlist %q: %w", op, name, err)
+	}
+
+	wordlist := &Wordlist{
+		Name:      name,
+		path:      path,
+		indexPath: indexPath,
+		lines:     uint64(indexInfo.Size()) / offsetSize,
+	}
+
+	log.Info("wordlist opened", slog.Uint64("lines", wordlist.lines))
+
+	return wordlist, nil
+}
+
+func (w *Wordlist) Lines() uint64 {
+	return w.lines
+}
+
+func (w *Wordlist) offset(line uint64) (int64, error) {
+	index, err := os.Open(w.indexPath)
+	if err != nil {
+		return 0, err
+	}
+	defer index.Close()
+
+	var buf [offsetSize]byte
+	if _, err := index.ReadAt(buf[:], int64(line*offsetSize)); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+func isIndexFresh(indexPath string, wordlistInfo fs.FileInfo) bool {
+	indexInfo, err := os.Stat(indexPath)
+	if err != nil {
+		return false
+	}
+
+	return !indexInfo.ModTime().Before(wordlistInfo.ModTime())
+}
+
+func buildWordlistIndex(path, indexPath string) error {
+	wordlist, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer wordlist.Close()
+
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(indexPath), filepath.Base(indexPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	reader := bufio.NewReaderSize(wordlist, 1<<20)
+	writer := bufio.NewWriterSize(tmp, 1<<20)
+
+	var buf [offsetSize]byte
+	offset := uint64(0)
+
+	for {
+		line, err := reader.ReadSlice('\n')
+		length := uint64(len(line))
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = reader.ReadSlice('\n')
+			length += uint64(len(line))
+		}
+
+		if length > 0 {
+			binary.LittleEndian.PutUint64(buf[:], offset)
+			if _, err := writer.Write(buf[:]); err != nil {
+				return err
+			}
+			offset += length
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), indexPath)
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatalistix/crack-hash-worker/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+const (
+	serviceName     = "crack-hash-worker"
+	shutdownTimeout = 5 * time.Second
+)
+
+type provider struct {
+	tracerProvider *sdktrace.TracerProvider
+	file           *os.File
+}
+
+func Setup(cfg config.TracingConfig) (io.Closer, error) {
+	const op = "tracing.Setup"
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	p := &provider{}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return p, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		p.file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("%s: error opening trace file %q: %w", op, cfg.File, err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(p.file))
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf("%s: unknown trace exporter %q", op, cfg.Exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: error creating %s trace exporter: %w", op, cfg.Exporter, err)
+	}
+
+	p.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		)),
+	)
+
+	otel.SetTracerProvider(p.tracerProvider)
+
+	return p, nil
+}
+
+func (p *provider) Close() error {
+	const op = "tracing.provider.Close"
+
+	if p.tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := p.tracerProvider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("%s: error shutting down tracer provider: %w", op, err)
+		}
+	}
+
+	if p.file != nil {
+		if err := p.file.Close(); err != nil {
+			return fmt.Errorf("%s: error closing trace file: %w", op, err)
+		}
+	}
+
+	return nil
+}
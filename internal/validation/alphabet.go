@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"encoding/hex"
+	"github.com/fatalistix/crack-hash-worker/internal/domain/model"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"unicode/utf8"
+)
+
+func alphabet(fl validator.FieldLevel) bool {
+	encoding, kind, _, ok := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+	if !ok || kind != reflect.String {
+		return false
+	}
+
+	value := fl.Field().String()
+
+	switch encoding.String() {
+	case model.AlphabetEncodingUTF8:
+		return utf8.ValidString(value) && uniqueChars(fl)
+	case model.AlphabetEncodingHex:
+		decoded, err := hex.DecodeString(value)
+		return err == nil && len(decoded) > 0 && uniqueBytes(decoded)
+	default:
+		return false
+	}
+}
+
+func uniqueBytes(data []byte) bool {
+	var visited [256]bool
+
+	for _, b := range data {
+		if visited[b] {
+			return false
+		}
+
+		visited[b] = true
+	}
+
+	return true
+}
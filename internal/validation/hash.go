@@ -1,12 +1,33 @@
 package validation
 
 import (
+	"encoding/hex"
+	"github.com/fatalistix/crack-hash-worker/internal/hasher"
 	"github.com/go-playground/validator/v10"
-	"regexp"
+	"reflect"
 )
 
-var md5Regex = regexp.MustCompile("^[a-fA-F0-9]{32}$")
+func hashAlgorithm(fl validator.FieldLevel) bool {
+	_, ok := hasher.Lookup(fl.Field().String())
+	return ok
+}
+
+func digest(fl validator.FieldLevel) bool {
+	algorithm, kind, _, ok := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+	if !ok || kind != reflect.String {
+		return false
+	}
+
+	h, ok := hasher.Lookup(algorithm.String())
+	if !ok {
+		return false
+	}
+
+	value := fl.Field().String()
+	if len(value) != hex.EncodedLen(h.Size()) {
+		return false
+	}
 
-func md5Hash(fl validator.FieldLevel) bool {
-	return md5Regex.Match([]byte(fl.Field().String()))
+	_, err := hex.DecodeString(value)
+	return err == nil
 }
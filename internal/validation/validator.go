@@ -22,8 +22,16 @@ func NewRequestValidator() (*RequestValidator, error) {
 		return nil, fmt.Errorf(`%s: error registering "uniquechars" validator: %w`, op, err)
 	}
 
-	if err := v.RegisterValidation("md5hash", md5Hash); err != nil {
-		return nil, fmt.Errorf(`%s: error registering "md5hash" validator: %w`, op, err)
+	if err := v.RegisterValidation("alphabet", alphabet); err != nil {
+		return nil, fmt.Errorf(`%s: error registering "alphabet" validator: %w`, op, err)
+	}
+
+	if err := v.RegisterValidation("hashalgorithm", hashAlgorithm); err != nil {
+		return nil, fmt.Errorf(`%s: error registering "hashalgorithm" validator: %w`, op, err)
+	}
+
+	if err := v.RegisterValidation("digest", digest); err != nil {
+		return nil, fmt.Errorf(`%s: error registering "digest" validator: %w`, op, err)
 	}
 
 	return &RequestValidator{